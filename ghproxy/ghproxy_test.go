@@ -18,6 +18,7 @@ package main
 
 import (
 	"bytes"
+	"crypto"
 	"crypto/rand"
 	"crypto/rsa"
 	"encoding/json"
@@ -82,7 +83,7 @@ func TestDiskCachePruning(t *testing.T) {
 		MaxRetries:      1,
 		Censor:          func(b []byte) []byte { return b },
 		AppID:           "123",
-		AppPrivateKey:   func() *rsa.PrivateKey { return rsaKey },
+		AppPrivateKey:   func() crypto.Signer { return rsaKey },
 		Bases:           []string{server.URL},
 		GraphqlEndpoint: server.URL,
 	})
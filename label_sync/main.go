@@ -162,6 +162,7 @@ func gatherOptions() (opts options, deprecatedOptions bool) {
 			"github-graphql-endpoint",
 			"github-token-path",
 			"github-hourly-tokens",
+			"github-throttle-window-tokens",
 			"github-allowed-burst":
 			newGitHubOptions = true
 		case "token",
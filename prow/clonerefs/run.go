@@ -17,8 +17,8 @@ limitations under the License.
 package clonerefs
 
 import (
+	"crypto"
 	"crypto/md5"
-	"crypto/rsa"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -27,7 +27,6 @@ import (
 	"strings"
 	"sync"
 
-	"github.com/dgrijalva/jwt-go/v4"
 	"github.com/sirupsen/logrus"
 	prowapi "k8s.io/test-infra/prow/apis/prowjobs/v1"
 	"k8s.io/test-infra/prow/config/secret"
@@ -84,9 +83,9 @@ func (o *Options) createRecords() []clone.Record {
 		tokenGenerator, userGenerator, _, err = github.NewClientFromOptions(logrus.Fields{}, github.ClientOptions{
 			Censor: secret.Censor,
 			AppID:  o.GitHubAppID,
-			AppPrivateKey: func() *rsa.PrivateKey {
+			AppPrivateKey: func() crypto.Signer {
 				raw := secret.GetSecret(o.GitHubAppPrivateKeyFile)
-				privateKey, err := jwt.ParseRSAPrivateKeyFromPEM(raw)
+				privateKey, err := github.ParseAppPrivateKeyFromPEM(raw)
 				if err != nil {
 					logrus.WithError(err).Error("Failed to parse GitHub App private key.")
 					return nil
@@ -23,6 +23,7 @@ import (
 	"sort"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"k8s.io/apimachinery/pkg/util/diff"
@@ -46,14 +47,14 @@ func TestOptions_Validate(t *testing.T) {
 				config: configflagutil.ConfigOptions{
 					ConfigPath: "dummy",
 				},
-				github: flagutil.GitHubOptions{TokenPath: "fake", ThrottleHourlyTokens: defaultTokens, ThrottleAllowBurst: defaultBurst},
+				github: flagutil.GitHubOptions{TokenPath: "fake", ThrottleWindowTokens: defaultTokens, ThrottleAllowBurst: defaultBurst, ThrottleWindow: time.Hour},
 			},
 			expectedErr: false,
 		},
 		{
 			name: "no config",
 			opt: options{
-				github: flagutil.GitHubOptions{TokenPath: "fake", ThrottleHourlyTokens: defaultTokens, ThrottleAllowBurst: defaultBurst},
+				github: flagutil.GitHubOptions{TokenPath: "fake", ThrottleWindowTokens: defaultTokens, ThrottleAllowBurst: defaultBurst, ThrottleWindow: time.Hour},
 			},
 			expectedErr: true,
 		},
@@ -63,7 +64,7 @@ func TestOptions_Validate(t *testing.T) {
 				config: configflagutil.ConfigOptions{
 					ConfigPath: "dummy",
 				},
-				github: flagutil.GitHubOptions{ThrottleHourlyTokens: defaultTokens, ThrottleAllowBurst: defaultBurst},
+				github: flagutil.GitHubOptions{ThrottleWindowTokens: defaultTokens, ThrottleAllowBurst: defaultBurst, ThrottleWindow: time.Hour},
 			},
 			expectedErr: false,
 		},
@@ -262,7 +262,7 @@ func validate(o options) error {
 			o.warnings = flagutil.NewStrings(append(o.warnings.Strings(), defaultWarnings...)...)
 		}
 	}
-	if o.github.AppID != "" && o.github.AppPrivateKeyPath != "" {
+	if o.github.AppID != "" && len(o.github.AppPrivateKeyPaths.Strings()) > 0 {
 		o.warnings.Add(validateGitHubAppInstallationWarning)
 	}
 
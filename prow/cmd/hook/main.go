@@ -122,9 +122,7 @@ func main() {
 	if o.github.TokenPath != "" {
 		tokens = append(tokens, o.github.TokenPath)
 	}
-	if o.github.AppPrivateKeyPath != "" {
-		tokens = append(tokens, o.github.AppPrivateKeyPath)
-	}
+	tokens = append(tokens, o.github.AppPrivateKeyPaths.Strings()...)
 	tokens = append(tokens, o.webhookSecretFile)
 
 	// This is necessary since slack token is optional.
@@ -203,7 +203,7 @@ func main() {
 			o.historyURI,
 			o.statusURI,
 			nil,
-			o.github.AppPrivateKeyPath != "",
+			len(o.github.AppPrivateKeyPaths.Strings()) > 0,
 		)
 		if err != nil {
 			logrus.WithError(err).Fatal("Error creating Tide controller.")
@@ -78,6 +78,13 @@ func AddWithParser[T any](path string, parsingFN func([]byte) (T, error)) (func(
 	return loader.get, secretAgent.add(path, loader)
 }
 
+// Remove stops watching the secret at path and forgets its value, so that it
+// is no longer censored either. Getters returned by AddWithParser keep the
+// last value.
+func Remove(path string) {
+	secretAgent.Remove(path)
+}
+
 // GetSecret returns the value of a secret stored in a map.
 func GetSecret(secretPath string) []byte {
 	return secretAgent.GetSecret(secretPath)
@@ -104,6 +111,7 @@ type agent struct {
 type secretReloader interface {
 	getRaw() []byte
 	start(reloadCensor func()) error
+	stop()
 }
 
 // Add registers a new path to the agent.
@@ -124,6 +132,19 @@ func (a *agent) add(path string, loader secretReloader) error {
 	return nil
 }
 
+// Remove stops watching the secret at path and forgets its value.
+func (a *agent) Remove(path string) {
+	a.Lock()
+	loader, ok := a.secretsMap[path]
+	delete(a.secretsMap, path)
+	a.Unlock()
+	if !ok {
+		return
+	}
+	loader.stop()
+	a.refreshCensorer()
+}
+
 // GetSecret returns the value of a secret stored in a map.
 func (a *agent) GetSecret(secretPath string) []byte {
 	a.RLock()
@@ -174,3 +174,33 @@ func testAddWithParser(t *testing.T) {
 	// expect secret to remain unchanged and an error in the parsing func
 	checkValueAndErr(2, errors.New(`strconv.Atoi: parsing "not-a-number": invalid syntax`))
 }
+
+func TestRemove(t *testing.T) {
+	t.Parallel()
+	secretPath := filepath.Join(t.TempDir(), "secret")
+	if err := os.WriteFile(secretPath, []byte("REMOVED-SECRET"), 0644); err != nil {
+		t.Fatalf("failed to write secret: %v", err)
+	}
+
+	agent := agent{}
+	if err := agent.Start([]string{secretPath}); err != nil {
+		t.Fatalf("failed to start a secret agent: %v", err)
+	}
+	loader := agent.secretsMap[secretPath].(*parsingSecretReloader[[]byte])
+
+	agent.Remove(secretPath)
+	if got := agent.GetSecret(secretPath); got != nil {
+		t.Errorf("expected no value for a removed secret, got %q", got)
+	}
+	if got := string(agent.Censor([]byte("REMOVED-SECRET"))); got != "REMOVED-SECRET" {
+		t.Errorf("expected a removed secret not to be censored, got %q", got)
+	}
+	select {
+	case <-loader.stopCh:
+	default:
+		t.Error("expected the reloading of a removed secret to be stopped")
+	}
+
+	// Removing an unknown secret is a no-op.
+	agent.Remove(secretPath)
+}
@@ -30,6 +30,9 @@ type parsingSecretReloader[T any] struct {
 	rawValue  []byte
 	parsed    T
 	parsingFN func([]byte) (T, error)
+
+	stopCh   chan struct{}
+	stopOnce sync.Once
 }
 
 func (p *parsingSecretReloader[T]) start(reloadCensor func()) error {
@@ -43,16 +46,33 @@ func (p *parsingSecretReloader[T]) start(reloadCensor func()) error {
 	p.lock.Unlock()
 	reloadCensor()
 
+	p.stopCh = make(chan struct{})
 	go p.reloadSecret(reloadCensor)
 	return nil
 }
 
+// stop ends the reloading of the secret.
+func (p *parsingSecretReloader[T]) stop() {
+	p.stopOnce.Do(func() {
+		if p.stopCh != nil {
+			close(p.stopCh)
+		}
+	})
+}
+
 func (p *parsingSecretReloader[T]) reloadSecret(reloadCensor func()) {
 	var lastModTime time.Time
 	logger := logrus.NewEntry(logrus.StandardLogger())
 
+	ticker := time.NewTicker(1 * time.Second)
+	defer ticker.Stop()
 	skips := 0
-	for range time.Tick(1 * time.Second) {
+	for {
+		select {
+		case <-p.stopCh:
+			return
+		case <-ticker.C:
+		}
 		if skips < 600 {
 			// Check if the file changed to see if it needs to be re-read.
 			secretStat, err := os.Stat(p.path)
@@ -0,0 +1,93 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package flagutiltest provides GitHubOptions for tests of components that
+// talk to a fake GitHub API. It is a separate package so that binaries do
+// not link the testing package.
+package flagutiltest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"flag"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"k8s.io/test-infra/prow/flagutil"
+)
+
+const (
+	// TestToken is the token clients from NewTestGitHubOptions authenticate
+	// with, e.g. to check the Authorization header in the test server.
+	TestToken = "ghp_flagutiltestToken"
+	// TestAppID is the ID of the github app of NewTestGitHubOptionsWithAppsAuth.
+	TestAppID = "1"
+)
+
+// NewTestGitHubOptions returns validated options for clients that send their
+// requests to server, authenticated with TestToken. The server may use TLS.
+// Retries back off quickly, so tests of failing requests do not take long.
+func NewTestGitHubOptions(t *testing.T, server *httptest.Server) *flagutil.GitHubOptions {
+	t.Helper()
+	tokenPath := filepath.Join(t.TempDir(), "oauth")
+	if err := os.WriteFile(tokenPath, []byte(TestToken), 0600); err != nil {
+		t.Fatalf("failed to write token: %v", err)
+	}
+	return parse(t, server, "--github-token-path="+tokenPath)
+}
+
+// NewTestGitHubOptionsWithAppsAuth returns validated options for clients that
+// send their requests to server, authenticated as the github app TestAppID
+// with a freshly generated private key. The key is at the only path in
+// AppPrivateKeyPaths, e.g. to verify the JWTs in the test server.
+func NewTestGitHubOptionsWithAppsAuth(t *testing.T, server *httptest.Server) *flagutil.GitHubOptions {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate private key: %v", err)
+	}
+	keyPath := filepath.Join(t.TempDir(), "key.pem")
+	if err := os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}), 0600); err != nil {
+		t.Fatalf("failed to write private key: %v", err)
+	}
+	return parse(t, server, "--github-token-path=", "--github-app-id="+TestAppID, "--github-app-private-key-path="+keyPath)
+}
+
+// parse returns validated options from the given flags, with clients that
+// send their requests to server through its transport, which trusts the
+// certificate of TLS servers.
+func parse(t *testing.T, server *httptest.Server, args ...string) *flagutil.GitHubOptions {
+	t.Helper()
+	o := &flagutil.GitHubOptions{}
+	fs := flag.NewFlagSet("github", flag.ContinueOnError)
+	o.AddCustomizedFlags(fs, flagutil.WithTransport(server.Client().Transport))
+	if err := fs.Parse(append([]string{
+		"--github-endpoint=" + server.URL,
+		"--github-graphql-endpoint=" + server.URL + "/graphql",
+		"--github-token-env=",
+		"--github-client.initial-delay=1ms",
+	}, args...)); err != nil {
+		t.Fatalf("failed to parse github flags: %v", err)
+	}
+	if err := o.Validate(false); err != nil {
+		t.Fatalf("failed to validate github options: %v", err)
+	}
+	return o
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flagutiltest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"k8s.io/test-infra/prow/flagutil"
+)
+
+func TestNewTestGitHubOptions(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name              string
+		newOptions        func(*testing.T, *httptest.Server) *flagutil.GitHubOptions
+		expectedAppAuth   bool
+		expectedAuthorize func(string) bool
+	}{
+		{
+			name:              "token",
+			newOptions:        NewTestGitHubOptions,
+			expectedAuthorize: func(auth string) bool { return auth == "Bearer "+TestToken },
+		},
+		{
+			name:            "apps auth",
+			newOptions:      NewTestGitHubOptionsWithAppsAuth,
+			expectedAppAuth: true,
+			// A JWT, as GET /app is authenticated as the app itself.
+			expectedAuthorize: func(auth string) bool { return strings.HasPrefix(auth, "Bearer ey") },
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			var lock sync.Mutex
+			var authorizations []string
+			server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				lock.Lock()
+				authorizations = append(authorizations, r.Header.Get("Authorization"))
+				lock.Unlock()
+				w.Write([]byte(`{"id": 1, "slug": "app"}`))
+			}))
+			defer server.Close()
+
+			o := tc.newOptions(t, server)
+			if o.HasAppAuth() != tc.expectedAppAuth {
+				t.Errorf("expected app auth to be %t", tc.expectedAppAuth)
+			}
+			client, err := o.GitHubClient(false)
+			if err != nil {
+				t.Fatalf("failed to construct client: %v", err)
+			}
+			if tc.expectedAppAuth {
+				_, err = client.GetAppWithContext(context.Background())
+			} else {
+				_, err = client.GetOrg("org")
+			}
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			lock.Lock()
+			defer lock.Unlock()
+			if len(authorizations) != 1 || !tc.expectedAuthorize(authorizations[0]) {
+				t.Errorf("unexpected authorizations %v", authorizations)
+			}
+		})
+	}
+}
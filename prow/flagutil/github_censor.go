@@ -0,0 +1,76 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flagutil
+
+import (
+	"context"
+
+	"k8s.io/test-infra/prow/config/secret"
+)
+
+// Censor returns the censor of the clients created from the options, which
+// censors all secrets known to the secret agent and the token from
+// --github-token-env if that is used. The token from --github-token-k8s-secret
+// is only censored once a client read it. It returns a censored copy of its
+// input and can be composed with other censors.
+func (o *GitHubOptions) Censor() func([]byte) []byte {
+	if token := o.envToken(); token != "" {
+		return accessTokenCensor(token)
+	}
+	state := o.clientState()
+	state.mu.RLock()
+	token := state.k8sSecretToken
+	state.mu.RUnlock()
+	if token != "" {
+		return accessTokenCensor(token)
+	}
+	return secret.Censor
+}
+
+// CensorAudit is called by CensorWithContext with the number of bytes it
+// censored, but not the secrets themselves, e.g. to record where secrets
+// would have leaked into logs.
+type CensorAudit func(censoredBytes int)
+
+type censorAuditContextKey struct{}
+
+// ContextWithCensorAudit returns a copy of ctx that makes CensorWithContext
+// call audit whenever it censors anything.
+func ContextWithCensorAudit(ctx context.Context, audit CensorAudit) context.Context {
+	return context.WithValue(ctx, censorAuditContextKey{}, audit)
+}
+
+// CensorWithContext returns a censored copy of content, see Censor. The
+// content itself is left untouched, so middleware can use it to check what
+// would be censored. If ctx carries a CensorAudit from ContextWithCensorAudit,
+// it is called when content holds secrets.
+func (o *GitHubOptions) CensorWithContext(ctx context.Context, content []byte) []byte {
+	censored := o.Censor()(content)
+	if audit, ok := ctx.Value(censorAuditContextKey{}).(CensorAudit); ok && audit != nil {
+		var count int
+		for i := range censored {
+			// Secrets are replaced by as many characters, so the positions line up.
+			if i < len(content) && censored[i] != content[i] {
+				count++
+			}
+		}
+		if count > 0 {
+			audit(count)
+		}
+	}
+	return censored
+}
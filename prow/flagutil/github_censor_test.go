@@ -0,0 +1,89 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flagutil
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestCensorWithContext(t *testing.T) {
+	t.Parallel()
+	const token = "censor-with-context-token"
+	o := &GitHubOptions{TokenPath: writeTestToken(t, token)}
+	if err := o.RegisterWithSecretAgent(); err != nil {
+		t.Fatalf("failed to register token: %v", err)
+	}
+
+	testCases := []struct {
+		name            string
+		content         string
+		audit           bool
+		expectedAudited []int
+	}{
+		{
+			name:    "secret is censored",
+			content: "Authorization: Bearer " + token,
+		},
+		{
+			name:            "censored bytes are audited",
+			content:         "Authorization: Bearer " + token,
+			audit:           true,
+			expectedAudited: []int{len(token)},
+		},
+		{
+			name:    "content without secrets is not audited",
+			content: "nothing to see here",
+			audit:   true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			ctx := context.Background()
+			var audited []int
+			if tc.audit {
+				ctx = ContextWithCensorAudit(ctx, func(censoredBytes int) { audited = append(audited, censoredBytes) })
+			}
+			content := []byte(tc.content)
+			censored := string(o.CensorWithContext(ctx, content))
+			if string(content) != tc.content {
+				t.Errorf("expected the content to be left untouched, got %q", content)
+			}
+			if strings.Contains(censored, token) {
+				t.Errorf("expected the token to be censored, got %q", censored)
+			}
+			if len(censored) != len(tc.content) {
+				t.Errorf("expected the censored content to keep its length, got %q", censored)
+			}
+			if len(audited) != len(tc.expectedAudited) || len(audited) > 0 && audited[0] != tc.expectedAudited[0] {
+				t.Errorf("expected audited %v, got %v", tc.expectedAudited, audited)
+			}
+		})
+	}
+}
+
+func TestCensorEnvToken(t *testing.T) {
+	t.Setenv("TEST_CENSOR_GITHUB_TOKEN", "censor-env-token")
+	o := &GitHubOptions{TokenEnvVar: "TEST_CENSOR_GITHUB_TOKEN"}
+	if censored := string(o.Censor()([]byte("token censor-env-token"))); strings.Contains(censored, "censor-env-token") {
+		t.Errorf("expected the token from the environment to be censored, got %q", censored)
+	}
+}
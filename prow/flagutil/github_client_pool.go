@@ -0,0 +1,83 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flagutil
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"k8s.io/test-infra/prow/github"
+)
+
+// GitHubClientPool returns size clients with the same credentials, each with
+// its own throttling and, unless a transport was set through WithTransport,
+// its own HTTP connection pool. This allows components to spread API calls
+// over several connections, e.g. through a ClientPool.
+//
+// The --github-hourly-tokens and --github-throttle-org budgets apply to every
+// client separately, so all clients together may use size times as many.
+func (o *GitHubOptions) GitHubClientPool(dryRun bool, size int) ([]github.Client, error) {
+	if size < 1 {
+		return nil, fmt.Errorf("the size of the github client pool must be positive, got %d", size)
+	}
+	clients := make([]github.Client, 0, size)
+	for i := 0; i < size; i++ {
+		options := o.baseClientOptions()
+		options.DryRun = dryRun
+		if o.transport == nil {
+			bases, sockets := o.clientBases()
+			options.BaseRoundTripper = o.withEndpointFailover(bases, o.newBaseRoundTripper(sockets))
+		}
+		_, _, client, err := o.newGitHubClient(options)
+		if err != nil {
+			return nil, fmt.Errorf("failed to construct github client %d of the pool: %w", i, err)
+		}
+		clients = append(clients, client)
+	}
+	o.logger().WithField("github-auth-method", o.authMethod()).Infof("Constructed pool of %d GitHub clients.", size)
+	return clients, nil
+}
+
+// ClientPool hands out GitHub clients so that each is used by one caller at a
+// time. It is safe for concurrent use.
+type ClientPool struct {
+	clients chan github.Client
+}
+
+// NewClientPool returns a ClientPool of the given clients, e.g. from
+// GitHubClientPool.
+func NewClientPool(clients []github.Client) *ClientPool {
+	pool := &ClientPool{clients: make(chan github.Client, len(clients))}
+	for _, client := range clients {
+		pool.clients <- client
+	}
+	return pool
+}
+
+// AcquireClient waits until a client is free and returns it along with a
+// function that returns it to the pool, which must be called once the client
+// is no longer used. It fails if ctx is done first.
+func (p *ClientPool) AcquireClient(ctx context.Context) (github.Client, func(), error) {
+	select {
+	case <-ctx.Done():
+		return nil, nil, ctx.Err()
+	case client := <-p.clients:
+		var once sync.Once
+		return client, func() { once.Do(func() { p.clients <- client }) }, nil
+	}
+}
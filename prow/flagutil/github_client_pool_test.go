@@ -0,0 +1,104 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flagutil
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"k8s.io/test-infra/prow/github"
+)
+
+func TestGitHubClientPool(t *testing.T) {
+	t.Parallel()
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer client-pool-token" {
+			http.Error(w, "Bad credentials", http.StatusUnauthorized)
+			return
+		}
+		requests.Add(1)
+		w.Write([]byte("{}"))
+	}))
+	defer server.Close()
+
+	o := &GitHubOptions{endpoint: NewStrings(server.URL), TokenPath: writeTestToken(t, "client-pool-token")}
+	if _, err := o.GitHubClientPool(false, 0); err == nil {
+		t.Error("expected an error for an empty pool, got none")
+	}
+	clients, err := o.GitHubClientPool(false, 3)
+	if err != nil {
+		t.Fatalf("failed to construct client pool: %v", err)
+	}
+	if len(clients) != 3 {
+		t.Fatalf("expected 3 clients, got %d", len(clients))
+	}
+	for _, client := range clients {
+		if _, err := client.(rateLimitClient).GetRateLimitWithContext(context.Background(), ""); err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+	}
+	if got := requests.Load(); got != 3 {
+		t.Errorf("expected 3 authenticated requests, got %d", got)
+	}
+}
+
+func TestClientPoolAcquireClient(t *testing.T) {
+	t.Parallel()
+	pool := NewClientPool([]github.Client{github.NewFakeClient(), github.NewFakeClient()})
+
+	first, releaseFirst, err := pool.AcquireClient(context.Background())
+	if err != nil {
+		t.Fatalf("failed to acquire client: %v", err)
+	}
+	second, releaseSecond, err := pool.AcquireClient(context.Background())
+	if err != nil {
+		t.Fatalf("failed to acquire client: %v", err)
+	}
+	if first == second {
+		t.Error("expected different clients to be handed out")
+	}
+	defer releaseSecond()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, _, err := pool.AcquireClient(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected acquiring from an exhausted pool to wait for the context, got %v", err)
+	}
+
+	releaseFirst()
+	// Releasing twice must not hand out the client twice.
+	releaseFirst()
+	third, releaseThird, err := pool.AcquireClient(context.Background())
+	if err != nil {
+		t.Fatalf("failed to acquire released client: %v", err)
+	}
+	defer releaseThird()
+	if third != first {
+		t.Error("expected the released client to be handed out again")
+	}
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, _, err := pool.AcquireClient(ctx); err == nil {
+		t.Error("expected a client released twice to be in the pool once, got another client")
+	}
+}
@@ -0,0 +1,198 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flagutil
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// endpointFailoverThreshold is the number of consecutive failed requests
+	// to an endpoint that are tolerated before failing over from it.
+	endpointFailoverThreshold = 3
+	// endpointFailoverRetryInterval is how often a request is sent to an
+	// endpoint that was failed over from, to check whether it recovered.
+	endpointFailoverRetryInterval = time.Minute
+)
+
+// WithEndpointFailover makes the GitHub clients stop sending requests to a
+// --github-endpoint once more than three consecutive requests to it failed
+// with a connection error or a 5xx response, e.g. because a ghproxy instance
+// is down. Its requests are sent to the next healthy endpoint instead, and
+// one request a minute is sent to it to find out whether it recovered. All
+// clients created from the options share what they learn about endpoints.
+// Without multiple endpoints, this has no effect.
+func WithEndpointFailover() FlagParameter {
+	return func(o *flagParams) {
+		o.endpointFailover = true
+	}
+}
+
+// endpointState is what an endpointFailover knows about one endpoint.
+type endpointState struct {
+	// failures is the number of consecutive failed requests.
+	failures int
+	// lastAttempt is when the last request was sent to the endpoint while it
+	// was failed over from.
+	lastAttempt time.Time
+}
+
+// endpointFailover tracks the health of the API endpoints and redirects the
+// requests to failed endpoints to healthy ones.
+type endpointFailover struct {
+	bases           []string
+	graphqlEndpoint string
+	logger          *logrus.Logger
+	now             func() time.Time
+
+	lock      sync.Mutex
+	endpoints []endpointState
+}
+
+func newEndpointFailover(bases []string, graphqlEndpoint string, logger *logrus.Logger) *endpointFailover {
+	return &endpointFailover{
+		bases:           bases,
+		graphqlEndpoint: graphqlEndpoint,
+		logger:          logger,
+		now:             time.Now,
+		endpoints:       make([]endpointState, len(bases)),
+	}
+}
+
+// withEndpointFailover returns rt wrapped by the endpoint failover shared by
+// the clients created from o if WithEndpointFailover was passed, else rt.
+func (o *GitHubOptions) withEndpointFailover(bases []string, rt http.RoundTripper) http.RoundTripper {
+	if !o.endpointFailover || len(bases) < 2 {
+		return rt
+	}
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	state := o.clientState()
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	if state.failover == nil || strings.Join(state.failover.bases, " ") != strings.Join(bases, " ") || state.failover.graphqlEndpoint != o.graphqlEndpoint {
+		state.failover = newEndpointFailover(bases, o.graphqlEndpoint, o.logger())
+	}
+	return &endpointFailoverRoundTripper{failover: state.failover, upstream: rt}
+}
+
+// endpointFailoverRoundTripper sends requests through upstream to the
+// endpoint the failover picks for them.
+type endpointFailoverRoundTripper struct {
+	failover *endpointFailover
+	upstream http.RoundTripper
+}
+
+func (rt *endpointFailoverRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	f := rt.failover
+	requested, path := f.match(req.URL.String())
+	if requested < 0 {
+		return rt.upstream.RoundTrip(req)
+	}
+	target := f.pick(requested)
+	if target != requested {
+		redirected, err := url.Parse(f.bases[target] + path)
+		if err != nil {
+			target = requested
+		} else {
+			req = req.Clone(req.Context())
+			req.URL = redirected
+			req.Host = ""
+		}
+	}
+	resp, err := rt.upstream.RoundTrip(req)
+	f.record(target, err != nil || resp.StatusCode >= http.StatusInternalServerError)
+	return resp, err
+}
+
+// match returns the index of the endpoint that uri is a request to along
+// with the rest of uri, or -1 if it is not. GraphQL requests are never
+// redirected, as the GraphQL endpoint is configured separately.
+func (f *endpointFailover) match(uri string) (int, string) {
+	if f.graphqlEndpoint != "" && strings.HasPrefix(uri, f.graphqlEndpoint) {
+		return -1, ""
+	}
+	index, length := -1, -1
+	for i, base := range f.bases {
+		// Prefer the longest match, in case one endpoint is a prefix of another.
+		if len(base) > length && strings.HasPrefix(uri, base) && (len(uri) == len(base) || strings.ContainsAny(uri[len(base):len(base)+1], "/?")) {
+			index, length = i, len(base)
+		}
+	}
+	if index < 0 {
+		return -1, ""
+	}
+	return index, uri[length:]
+}
+
+// failed returns whether the endpoint at index i is failed over from. The
+// lock must be held.
+func (f *endpointFailover) failed(i int) bool {
+	return f.endpoints[i].failures > endpointFailoverThreshold
+}
+
+// next returns the first healthy endpoint after the one at index i, wrapping
+// around, or i if there is none. The lock must be held.
+func (f *endpointFailover) next(i int) int {
+	for offset := 1; offset < len(f.bases); offset++ {
+		if j := (i + offset) % len(f.bases); !f.failed(j) {
+			return j
+		}
+	}
+	return i
+}
+
+// pick returns the endpoint a request to the endpoint at index i is sent to.
+func (f *endpointFailover) pick(i int) int {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if !f.failed(i) {
+		return i
+	}
+	if now := f.now(); now.Sub(f.endpoints[i].lastAttempt) >= endpointFailoverRetryInterval {
+		f.endpoints[i].lastAttempt = now
+		return i
+	}
+	return f.next(i)
+}
+
+// record updates the health of the endpoint at index i with the outcome of a
+// request to it and logs when it is failed over from or recovered.
+func (f *endpointFailover) record(i int, failed bool) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	state := &f.endpoints[i]
+	if !failed {
+		if f.failed(i) {
+			f.logger.WithField("endpoint", f.bases[i]).Info("GitHub API endpoint recovered, sending requests to it again.")
+		}
+		*state = endpointState{}
+		return
+	}
+	state.failures++
+	if state.failures == endpointFailoverThreshold+1 {
+		state.lastAttempt = f.now()
+		f.logger.WithField("endpoint", f.bases[i]).WithField("failover", f.bases[f.next(i)]).Warnf("GitHub API endpoint failed %d consecutive requests, failing over until it recovers.", state.failures)
+	}
+}
@@ -0,0 +1,147 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flagutil
+
+import (
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestEndpointFailover(t *testing.T) {
+	t.Parallel()
+	var primaryDown atomic.Bool
+	var primaryRequests, secondaryRequests atomic.Int32
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		primaryRequests.Add(1)
+		if primaryDown.Load() {
+			http.Error(w, "ghproxy is down", http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte("{}"))
+	}))
+	defer primary.Close()
+	secondary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		secondaryRequests.Add(1)
+		if r.URL.Path != "/repos/org/repo" {
+			t.Errorf("expected the path to be kept, got %s", r.URL.Path)
+		}
+		w.Write([]byte("{}"))
+	}))
+	defer secondary.Close()
+
+	failover := newEndpointFailover([]string{primary.URL, secondary.URL}, primary.URL+"/graphql", logrus.StandardLogger())
+	now := time.Now()
+	failover.now = func() time.Time { return now }
+	client := &http.Client{Transport: &endpointFailoverRoundTripper{failover: failover, upstream: http.DefaultTransport}}
+	get := func(url string) {
+		t.Helper()
+		resp, err := client.Get(url)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		resp.Body.Close()
+	}
+	expectRequests := func(expectedPrimary, expectedSecondary int32) {
+		t.Helper()
+		if got := primaryRequests.Swap(0); got != expectedPrimary {
+			t.Errorf("expected %d requests to the primary endpoint, got %d", expectedPrimary, got)
+		}
+		if got := secondaryRequests.Swap(0); got != expectedSecondary {
+			t.Errorf("expected %d requests to the secondary endpoint, got %d", expectedSecondary, got)
+		}
+	}
+
+	primaryDown.Store(true)
+	for i := 0; i < endpointFailoverThreshold+1; i++ {
+		get(primary.URL + "/repos/org/repo")
+	}
+	expectRequests(endpointFailoverThreshold+1, 0)
+
+	get(primary.URL + "/repos/org/repo")
+	expectRequests(0, 1)
+
+	get(primary.URL + "/graphql")
+	expectRequests(1, 0)
+
+	now = now.Add(endpointFailoverRetryInterval)
+	get(primary.URL + "/repos/org/repo")
+	get(primary.URL + "/repos/org/repo")
+	expectRequests(1, 1)
+
+	primaryDown.Store(false)
+	now = now.Add(endpointFailoverRetryInterval)
+	get(primary.URL + "/repos/org/repo")
+	get(primary.URL + "/repos/org/repo")
+	expectRequests(2, 0)
+}
+
+func TestWithEndpointFailover(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name       string
+		params     []FlagParameter
+		endpoints  []string
+		expectWrap bool
+	}{
+		{
+			name:      "disabled",
+			endpoints: []string{"http://ghproxy", "https://api.github.com"},
+		},
+		{
+			name:      "single endpoint",
+			params:    []FlagParameter{WithEndpointFailover()},
+			endpoints: []string{"http://ghproxy"},
+		},
+		{
+			name:       "multiple endpoints",
+			params:     []FlagParameter{WithEndpointFailover()},
+			endpoints:  []string{"http://ghproxy", "https://api.github.com"},
+			expectWrap: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			o := &GitHubOptions{}
+			fs := flag.NewFlagSet("github", flag.ContinueOnError)
+			o.AddCustomizedFlags(fs, tc.params...)
+			var args []string
+			for _, endpoint := range tc.endpoints {
+				args = append(args, "--github-endpoint="+endpoint)
+			}
+			if err := fs.Parse(args); err != nil {
+				t.Fatalf("failed to parse flags: %v", err)
+			}
+			first := o.baseClientOptions().BaseRoundTripper
+			_, wrapped := first.(*endpointFailoverRoundTripper)
+			if wrapped != tc.expectWrap {
+				t.Fatalf("expected the transport to be wrapped: %t, got %T", tc.expectWrap, first)
+			}
+			if wrapped && first.(*endpointFailoverRoundTripper).failover != o.baseClientOptions().BaseRoundTripper.(*endpointFailoverRoundTripper).failover {
+				t.Error("expected clients to share the endpoint failover")
+			}
+		})
+	}
+}
@@ -0,0 +1,153 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flagutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+
+	"k8s.io/test-infra/prow/github"
+)
+
+// rateLimitStatusTTL is how long RateLimitStatus caches the rate limits.
+const rateLimitStatusTTL = time.Minute
+
+// rateLimitClient is the part of the clients of prow/github that HealthCheck
+// and RateLimitStatus use. Getting the rate limits is not part of
+// github.Client.
+type rateLimitClient interface {
+	UsesAppAuth() bool
+	GetAppWithContext(ctx context.Context) (*github.App, error)
+	GetRateLimitWithContext(ctx context.Context, org string) (*github.RateLimits, error)
+}
+
+// healthCheck holds the client that HealthCheck and RateLimitStatus reuse
+// across calls.
+type healthCheck struct {
+	client rateLimitClient
+	// orgs that have their own token in the --github-token-path directory.
+	orgs []string
+
+	rateLimitLock    sync.Mutex
+	rateLimits       *github.RateLimits
+	rateLimitFetched time.Time
+}
+
+// HealthCheck verifies the configured credentials with a lightweight
+// authenticated request, which makes it suitable for liveness and readiness
+// probes. GitHub Apps credentials are checked with GET /app, tokens with
+// GET /rate_limit, which does not count against the API budget. When
+// --github-token-path is a directory, the token of every org is checked.
+//
+// The client used for the check is constructed on the first call and reused
+// afterwards. HealthCheck is safe for concurrent use and must be called after
+// Validate.
+func (o *GitHubOptions) HealthCheck(ctx context.Context) error {
+	ctx = o.AnnotateContext(ctx)
+	check, err := o.healthCheckClient()
+	if err != nil {
+		return fmt.Errorf("failed to construct github client for the health check: %w", err)
+	}
+	if check.client.UsesAppAuth() {
+		if _, err := check.client.GetAppWithContext(ctx); err != nil {
+			return fmt.Errorf("health check of github app %s failed: %w", o.AppID, err)
+		}
+		return nil
+	}
+	if _, err := check.client.GetRateLimitWithContext(ctx, ""); err != nil {
+		return fmt.Errorf("health check of github token failed: %w", err)
+	}
+	var errs []error
+	for _, org := range check.orgs {
+		if _, err := check.client.GetRateLimitWithContext(ctx, org); err != nil {
+			errs = append(errs, fmt.Errorf("health check of github token for org %s failed: %w", org, err))
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// RateLimitStatus returns the API budget of the configured credentials from
+// GET /rate_limit, which does not count against the budget itself. With
+// GitHub App auth, it is the budget of --github-app-installation-id, so an
+// error is returned if that is not set. The result is cached for a minute,
+// the caller must not modify it.
+//
+// Like HealthCheck, the client is constructed on the first call and reused
+// afterwards. RateLimitStatus is safe for concurrent use and must be called
+// after Validate.
+func (o *GitHubOptions) RateLimitStatus(ctx context.Context) (*github.RateLimits, error) {
+	if o.AppID != "" && o.AppInstallationID == 0 {
+		return nil, errors.New("the github rate limit status with github app auth requires --github-app-installation-id")
+	}
+	ctx = o.AnnotateContext(ctx)
+	check, err := o.healthCheckClient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to construct github client for the rate limit status: %w", err)
+	}
+	check.rateLimitLock.Lock()
+	defer check.rateLimitLock.Unlock()
+	if check.rateLimits != nil && time.Since(check.rateLimitFetched) < rateLimitStatusTTL {
+		return check.rateLimits, nil
+	}
+	limits, err := check.client.GetRateLimitWithContext(ctx, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get github rate limit status: %w", err)
+	}
+	check.rateLimits, check.rateLimitFetched = limits, time.Now()
+	return limits, nil
+}
+
+func (o *GitHubOptions) healthCheckClient() (*healthCheck, error) {
+	state := o.clientState()
+	state.healthCheckMu.Lock()
+	defer state.healthCheckMu.Unlock()
+	if state.healthCheck != nil {
+		return state.healthCheck, nil
+	}
+
+	check := &healthCheck{}
+	if info, err := os.Stat(o.TokenPath); o.TokenPath != "" && o.AppID == "" && err == nil && info.IsDir() {
+		paths, err := orgTokenPaths(o.TokenPath)
+		if err != nil {
+			return nil, err
+		}
+		for org := range paths {
+			if org != defaultOrgTokenFile {
+				check.orgs = append(check.orgs, org)
+			}
+		}
+		sort.Strings(check.orgs)
+	}
+	_, _, client, err := o.newGitHubClient(o.baseClientOptions())
+	if err != nil {
+		return nil, err
+	}
+	rateLimits, ok := client.(rateLimitClient)
+	if !ok {
+		return nil, fmt.Errorf("github client %T cannot get the rate limits", client)
+	}
+	check.client = rateLimits
+	state.healthCheck = check
+	return check, nil
+}
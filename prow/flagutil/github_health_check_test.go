@@ -0,0 +1,206 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flagutil
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"k8s.io/test-infra/prow/github"
+)
+
+func TestHealthCheck(t *testing.T) {
+	var lock sync.Mutex
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		if strings.HasPrefix(auth, "Bearer ey") {
+			// A JWT of apps auth.
+			auth = "jwt"
+		}
+		lock.Lock()
+		requests = append(requests, r.URL.Path+" "+auth)
+		lock.Unlock()
+		if strings.HasPrefix(auth, "Bearer invalid-") {
+			http.Error(w, "Bad credentials", http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte("{}"))
+	}))
+	defer server.Close()
+
+	tokenDir := func(tokens map[string]string) string {
+		dir := t.TempDir()
+		for name, token := range tokens {
+			if err := os.WriteFile(filepath.Join(dir, name), []byte(token), 0600); err != nil {
+				t.Fatalf("failed to write token: %v", err)
+			}
+		}
+		return dir
+	}
+
+	testCases := []struct {
+		name             string
+		options          GitHubOptions
+		expectedRequests []string
+		expectedErr      string
+	}{
+		{
+			name:             "valid token",
+			options:          GitHubOptions{TokenPath: writeTestToken(t, "valid-health-check-token")},
+			expectedRequests: []string{"/rate_limit Bearer valid-health-check-token"},
+		},
+		{
+			name:             "invalid token",
+			options:          GitHubOptions{TokenPath: writeTestToken(t, "invalid-health-check-token")},
+			expectedRequests: []string{"/rate_limit Bearer invalid-health-check-token"},
+			expectedErr:      "health check of github token failed",
+		},
+		{
+			name:    "token of every org is checked",
+			options: GitHubOptions{TokenPath: tokenDir(map[string]string{"default": "valid-health-check-token", "org-a": "valid-health-check-token-a", "org-b": "invalid-health-check-token"})},
+			expectedRequests: []string{
+				"/rate_limit Bearer valid-health-check-token",
+				"/rate_limit Bearer valid-health-check-token-a",
+				"/rate_limit Bearer invalid-health-check-token",
+			},
+			expectedErr: "health check of github token for org org-b failed",
+		},
+		{
+			name:             "github app",
+			options:          GitHubOptions{AppID: "10", AppPrivateKeyPaths: NewStrings(writeTestAppPrivateKey(t))},
+			expectedRequests: []string{"/app jwt"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			requests = nil
+			tc.options.endpoint = NewStrings(server.URL)
+			err := tc.options.HealthCheck(context.Background())
+			if (err != nil) != (tc.expectedErr != "") || err != nil && !strings.Contains(err.Error(), tc.expectedErr) {
+				t.Errorf("expected error containing %q, got %v", tc.expectedErr, err)
+			}
+			sort.Strings(requests)
+			sort.Strings(tc.expectedRequests)
+			if diff := cmp.Diff(tc.expectedRequests, requests); diff != "" {
+				t.Errorf("unexpected requests: %s", diff)
+			}
+		})
+	}
+}
+
+func TestHealthCheckReusesClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte("{}"))
+	}))
+	defer server.Close()
+
+	o := &GitHubOptions{endpoint: NewStrings(server.URL), TokenPath: writeTestToken(t, "health-check-token")}
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := o.HealthCheck(context.Background()); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	check := o.state.healthCheck
+	if err := o.HealthCheck(context.Background()); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if o.state.healthCheck != check {
+		t.Error("expected the health check client to be reused")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := o.HealthCheck(ctx); err == nil {
+		t.Error("expected an error for a cancelled context, got none")
+	}
+}
+
+func TestRateLimitStatus(t *testing.T) {
+	var lock sync.Mutex
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		requests++
+		remaining := 5000 - requests
+		lock.Unlock()
+		if r.Header.Get("Authorization") == "Bearer invalid-rate-limit-token" {
+			http.Error(w, "Bad credentials", http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprintf(w, `{"resources": {"core": {"limit": 5000, "remaining": %d}}}`, remaining)
+	}))
+	defer server.Close()
+
+	o := &GitHubOptions{endpoint: NewStrings(server.URL), TokenPath: writeTestToken(t, "valid-rate-limit-token")}
+	for i := 0; i < 2; i++ {
+		limits, err := o.RateLimitStatus(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if diff := cmp.Diff(github.RateLimit{Limit: 5000, Remaining: 4999}, limits.Resources["core"]); diff != "" {
+			t.Errorf("unexpected rate limit: %s", diff)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("expected the rate limits to be cached, got %d requests", requests)
+	}
+
+	o.state.healthCheck.rateLimitFetched = time.Now().Add(-rateLimitStatusTTL)
+	limits, err := o.RateLimitStatus(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if remaining := limits.Resources["core"].Remaining; remaining != 4998 {
+		t.Errorf("expected the rate limits to be fetched again after they expired, got %d remaining", remaining)
+	}
+
+	invalid := &GitHubOptions{endpoint: NewStrings(server.URL), TokenPath: writeTestToken(t, "invalid-rate-limit-token")}
+	if _, err := invalid.RateLimitStatus(context.Background()); err == nil {
+		t.Error("expected an error for invalid credentials, got none")
+	}
+	if invalid.state.healthCheck.rateLimits != nil {
+		t.Error("expected failed requests not to be cached")
+	}
+
+	app := &GitHubOptions{endpoint: NewStrings(server.URL), AppID: "10", AppPrivateKeyPaths: NewStrings(writeTestAppPrivateKey(t))}
+	before := requests
+	if _, err := app.RateLimitStatus(context.Background()); err == nil || !strings.Contains(err.Error(), "--github-app-installation-id") {
+		t.Errorf("expected an error naming --github-app-installation-id for app auth without an installation, got %v", err)
+	}
+	if requests != before {
+		t.Errorf("expected no requests for app auth without an installation, got %d", requests-before)
+	}
+}
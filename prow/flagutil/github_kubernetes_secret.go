@@ -0,0 +1,118 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flagutil
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/rest"
+)
+
+// kubernetesSecretTimeout bounds reading the token of --github-token-k8s-secret.
+const kubernetesSecretTimeout = 30 * time.Second
+
+// parseKubernetesSecretRef splits a namespace/secret-name/key reference as
+// passed to --github-token-k8s-secret.
+func parseKubernetesSecretRef(ref string) (namespace, name, key string, err error) {
+	parts := strings.Split(ref, "/")
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+		return "", "", "", fmt.Errorf("--github-token-k8s-secret must be in namespace/secret-name/key format, got %q", ref)
+	}
+	return parts[0], parts[1], parts[2], nil
+}
+
+// inClusterConfig returns the config of the cluster the component runs in,
+// with an error naming --github-token-k8s-secret if it runs outside of one.
+func inClusterConfig() (*rest.Config, error) {
+	config, err := rest.InClusterConfig()
+	if errors.Is(err, rest.ErrNotInCluster) {
+		return nil, errors.New("--github-token-k8s-secret can only be used inside a Kubernetes cluster")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the in-cluster config for --github-token-k8s-secret: %w", err)
+	}
+	return config, nil
+}
+
+// loadFromKubernetesSecret reads the value of the key of the Secret that ref
+// refers to in namespace/secret-name/key format, using the in-cluster config.
+func loadFromKubernetesSecret(ref string) ([]byte, error) {
+	config, err := inClusterConfig()
+	if err != nil {
+		return nil, err
+	}
+	client, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to construct the Kubernetes client for --github-token-k8s-secret: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), kubernetesSecretTimeout)
+	defer cancel()
+	return readKubernetesSecret(ctx, client.CoreV1(), ref)
+}
+
+// readKubernetesSecret reads the value that ref refers to through client.
+// Surrounding whitespace is trimmed, like for tokens read from files.
+func readKubernetesSecret(ctx context.Context, client corev1.SecretsGetter, ref string) ([]byte, error) {
+	namespace, name, key, err := parseKubernetesSecretRef(ref)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := client.Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get secret %s/%s: %w", namespace, name, err)
+	}
+	value, ok := secret.Data[key]
+	if !ok {
+		return nil, fmt.Errorf("secret %s/%s has no key %s", namespace, name, key)
+	}
+	value = bytes.TrimSpace(value)
+	if len(value) == 0 {
+		return nil, fmt.Errorf("key %s of secret %s/%s is empty", key, namespace, name)
+	}
+	return value, nil
+}
+
+// kubernetesSecretToken returns the token from --github-token-k8s-secret. It
+// is read once and reused by all clients created from the options, so
+// rotating it requires a restart.
+func (o *GitHubOptions) kubernetesSecretToken() (string, error) {
+	state := o.clientState()
+	state.mu.RLock()
+	token := state.k8sSecretToken
+	state.mu.RUnlock()
+	if token != "" {
+		return token, nil
+	}
+	// The lock is not held while talking to the API server, clients that are
+	// created concurrently may read the secret more than once.
+	raw, err := loadFromKubernetesSecret(o.TokenK8sSecret)
+	if err != nil {
+		return "", err
+	}
+	state.mu.Lock()
+	state.k8sSecretToken = string(raw)
+	state.mu.Unlock()
+	return string(raw), nil
+}
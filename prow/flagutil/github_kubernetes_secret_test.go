@@ -0,0 +1,136 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flagutil
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestReadKubernetesSecret(t *testing.T) {
+	t.Parallel()
+	client := fake.NewSimpleClientset(&v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "prow", Name: "github-token"},
+		Data: map[string][]byte{
+			"oauth": []byte("k8s-secret-token\n"),
+			"empty": []byte(" "),
+		},
+	})
+
+	testCases := []struct {
+		name          string
+		ref           string
+		expected      string
+		expectedError string
+	}{
+		{
+			name:     "token is read and trimmed",
+			ref:      "prow/github-token/oauth",
+			expected: "k8s-secret-token",
+		},
+		{
+			name:          "invalid reference",
+			ref:           "prow/github-token",
+			expectedError: "namespace/secret-name/key format",
+		},
+		{
+			name:          "missing secret",
+			ref:           "prow/other/oauth",
+			expectedError: "failed to get secret prow/other",
+		},
+		{
+			name:          "missing key",
+			ref:           "prow/github-token/token",
+			expectedError: "has no key token",
+		},
+		{
+			name:          "empty key",
+			ref:           "prow/github-token/empty",
+			expectedError: "is empty",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			value, err := readKubernetesSecret(context.Background(), client.CoreV1(), tc.ref)
+			if tc.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.expectedError) {
+					t.Errorf("expected an error containing %q, got %v", tc.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to read secret: %v", err)
+			}
+			if string(value) != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, value)
+			}
+		})
+	}
+}
+
+func TestTokenK8sSecretValidation(t *testing.T) {
+	// Validation must not find the in-cluster config of the test environment.
+	t.Setenv("KUBERNETES_SERVICE_HOST", "")
+	testCases := []struct {
+		name          string
+		in            *GitHubOptions
+		expectedError string
+	}{
+		{
+			name:          "token path",
+			in:            &GitHubOptions{TokenK8sSecret: "prow/github-token/oauth", TokenPath: "/etc/github/oauth"},
+			expectedError: "mutually exclusive",
+		},
+		{
+			name:          "app auth",
+			in:            &GitHubOptions{TokenK8sSecret: "prow/github-token/oauth", AppID: "10", AppPrivateKeyEnvVar: "TEST_K8S_SECRET_APP_PRIVATE_KEY"},
+			expectedError: "mutually exclusive",
+		},
+		{
+			name:          "invalid reference",
+			in:            &GitHubOptions{TokenK8sSecret: "prow/github-token/oauth/extra"},
+			expectedError: "namespace/secret-name/key format",
+		},
+		{
+			name:          "outside of a cluster",
+			in:            &GitHubOptions{TokenK8sSecret: "prow/github-token/oauth"},
+			expectedError: "can only be used inside a Kubernetes cluster",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.in.Validate(false)
+			if err == nil || !strings.Contains(err.Error(), tc.expectedError) {
+				t.Fatalf("expected an error containing %q, got %v", tc.expectedError, err)
+			}
+			var target *ErrMissingCredentials
+			if !errors.As(err, &target) {
+				t.Errorf("expected an ErrMissingCredentials, got %T", err)
+			}
+		})
+	}
+}
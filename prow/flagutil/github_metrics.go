@@ -0,0 +1,103 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flagutil
+
+import (
+	"errors"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// appMetrics instruments GitHub App authentication of the clients created
+// from GitHubOptions. See WithMetrics.
+type appMetrics struct {
+	tokenGenerationDuration   *prometheus.HistogramVec
+	jwtSigningErrors          prometheus.Counter
+	throttleHourlyTokenBudget *prometheus.GaugeVec
+}
+
+// WithMetrics registers metrics about GitHub App installation token fetches,
+// JWT signing and the throttling budget with the given registerer. They are recorded for every client
+// created from the options.
+func WithMetrics(registerer prometheus.Registerer) FlagParameter {
+	return func(o *flagParams) {
+		o.metrics = newAppMetrics(registerer)
+	}
+}
+
+func newAppMetrics(registerer prometheus.Registerer) *appMetrics {
+	m := &appMetrics{
+		tokenGenerationDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "prow_github_app",
+			Name:      "token_generation_duration_seconds",
+			Help:      "Latency of fetching GitHub App installation tokens from GitHub, by result. Tokens served from the cache are not counted.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"result"}),
+		jwtSigningErrors: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "prow_github_app",
+			Name:      "jwt_signing_errors_total",
+			Help:      "Number of times the JWT for GitHub App authentication could not be signed.",
+		}),
+		throttleHourlyTokenBudget: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "prow_github_app",
+			Name:      "throttle_hourly_token_budget",
+			Help:      "Hourly token budget the client-side throttler is configured with, by org. The global throttler uses org \"*\" and zero means throttling is disabled.",
+		}, []string{"org"}),
+	}
+	m.tokenGenerationDuration = registerOrReuse(registerer, m.tokenGenerationDuration)
+	m.jwtSigningErrors = registerOrReuse(registerer, m.jwtSigningErrors)
+	m.throttleHourlyTokenBudget = registerOrReuse(registerer, m.throttleHourlyTokenBudget)
+	return m
+}
+
+// registerOrReuse registers c, or returns the identical collector that was
+// registered before, so that the same registerer can be passed multiple times.
+func registerOrReuse[T prometheus.Collector](registerer prometheus.Registerer, c T) T {
+	if err := registerer.Register(c); err != nil {
+		var are prometheus.AlreadyRegisteredError
+		if errors.As(err, &are) {
+			if existing, ok := are.ExistingCollector.(T); ok {
+				return existing
+			}
+		}
+		panic(err)
+	}
+	return c
+}
+
+// observeInstallationTokenFetch is the github.ClientOptions hook for
+// installation token fetches, which covers both the tokens for API requests
+// and those handed out for git.
+func (m *appMetrics) observeInstallationTokenFetch(duration time.Duration, err error) {
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+	m.tokenGenerationDuration.WithLabelValues(result).Observe(duration.Seconds())
+}
+
+func (m *appMetrics) observeJWTSigningError(error) {
+	m.jwtSigningErrors.Inc()
+}
+
+func (m *appMetrics) observeThrottle(org string, hourlyTokens int) {
+	if org == "" {
+		org = "*"
+	}
+	m.throttleHourlyTokenBudget.WithLabelValues(org).Set(float64(hourlyTokens))
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flagutil
+
+import (
+	"errors"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestWithMetrics(t *testing.T) {
+	registry := prometheus.NewRegistry()
+
+	o := &GitHubOptions{}
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	o.AddCustomizedFlags(fs, ThrottlerDefaults(300, 100), WithMetrics(registry))
+	if err := fs.Parse([]string{"--github-token-path="}); err != nil {
+		t.Fatalf("failed to parse flags: %v", err)
+	}
+	if o.metrics == nil {
+		t.Fatal("expected metrics to be set")
+	}
+	// Registering a second time with the same registerer must not fail.
+	other := &GitHubOptions{}
+	other.AddCustomizedFlags(flag.NewFlagSet("other", flag.ContinueOnError), WithMetrics(registry))
+	if other.metrics.jwtSigningErrors != o.metrics.jwtSigningErrors {
+		t.Error("expected the already registered collectors to be reused")
+	}
+
+	if err := o.Validate(false); err != nil {
+		t.Fatalf("failed to validate: %v", err)
+	}
+	if _, err := o.githubClient(false); err != nil {
+		t.Fatalf("failed to construct client: %v", err)
+	}
+	if got := testutil.ToFloat64(o.metrics.throttleHourlyTokenBudget.WithLabelValues("*")); got != 300 {
+		t.Errorf("expected global throttle gauge to be 300, got %v", got)
+	}
+
+	o.metrics.observeInstallationTokenFetch(time.Second, nil)
+	o.metrics.observeInstallationTokenFetch(time.Second, errors.New("injected error"))
+	if n := testutil.CollectAndCount(o.metrics.tokenGenerationDuration); n != 2 {
+		t.Errorf("expected a histogram per result, got %d", n)
+	}
+
+	o.metrics.observeJWTSigningError(errors.New("injected error"))
+	if got := testutil.ToFloat64(o.metrics.jwtSigningErrors); got != 1 {
+		t.Errorf("expected one jwt signing error, got %v", got)
+	}
+}
+
+func TestWithMetricsInstallationTokenFetches(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/app/installations/1/access_tokens" {
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"token": "ghs_metricsInstallationToken", "expires_at": "` + time.Now().Add(time.Hour).Format(time.RFC3339) + `"}`))
+			return
+		}
+		w.Write([]byte(`{"login": "org"}`))
+	}))
+	defer server.Close()
+
+	o := &GitHubOptions{}
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	o.AddCustomizedFlags(fs, WithMetrics(prometheus.NewRegistry()))
+	if err := fs.Parse([]string{
+		"--github-endpoint=" + server.URL,
+		"--github-token-path=",
+		"--github-app-id=1",
+		"--github-app-installation-id=1",
+		"--github-app-private-key-path=" + writeTestAppPrivateKey(t),
+	}); err != nil {
+		t.Fatalf("failed to parse flags: %v", err)
+	}
+	if err := o.Validate(false); err != nil {
+		t.Fatalf("failed to validate: %v", err)
+	}
+	client, err := o.GitHubClient(false)
+	if err != nil {
+		t.Fatalf("failed to construct client: %v", err)
+	}
+	// The token is fetched for the request and served from the cache after.
+	for i := 0; i < 2; i++ {
+		if _, err := client.GetOrg("org"); err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+	}
+	var fetches dto.Metric
+	if err := o.metrics.tokenGenerationDuration.WithLabelValues("success").(prometheus.Metric).Write(&fetches); err != nil {
+		t.Fatalf("failed to read histogram: %v", err)
+	}
+	if got := fetches.GetHistogram().GetSampleCount(); got != 1 {
+		t.Errorf("expected one installation token fetch, got %d", got)
+	}
+}
@@ -0,0 +1,127 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flagutil
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNoExpiry is returned by TokenExpiresAt for tokens that do not expire.
+var ErrNoExpiry = errors.New("the GitHub token does not expire")
+
+// tokenExpirationHeader is set by GitHub on responses to requests made with
+// a token that expires.
+const tokenExpirationHeader = "GitHub-Authentication-Token-Expiration"
+
+// TokenExpiresAt returns when the token used by the last client created
+// through GitHubClient expires, as reported by GitHub on the last successful
+// API call of that client. It returns ErrNoExpiry if the token does not
+// expire, and an error if no call was made yet.
+func (o *GitHubOptions) TokenExpiresAt() (time.Time, error) {
+	state := o.clientState()
+	state.mu.RLock()
+	expiry := state.tokenExpiry
+	state.mu.RUnlock()
+	if expiry == nil {
+		return time.Time{}, errors.New("no github client was created yet")
+	}
+	return expiry.get()
+}
+
+// tokenExpiry records the token expiration reported in GitHub responses.
+type tokenExpiry struct {
+	lock      sync.RWMutex
+	seen      bool
+	expiresAt time.Time
+	err       error
+}
+
+func (e *tokenExpiry) get() (time.Time, error) {
+	e.lock.RLock()
+	defer e.lock.RUnlock()
+	if !e.seen {
+		return time.Time{}, errors.New("no GitHub API call was made yet")
+	}
+	if e.err != nil {
+		return time.Time{}, e.err
+	}
+	if e.expiresAt.IsZero() {
+		return time.Time{}, ErrNoExpiry
+	}
+	return e.expiresAt, nil
+}
+
+func (e *tokenExpiry) record(header string) {
+	var expiresAt time.Time
+	var err error
+	if header != "" {
+		expiresAt, err = parseTokenExpiration(header)
+	}
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	e.seen, e.expiresAt, e.err = true, expiresAt, err
+}
+
+// parseTokenExpiration parses the value of the token expiration header, which
+// GitHub sends like "2023-07-08 00:00:00 UTC" or with a numeric offset.
+func parseTokenExpiration(value string) (time.Time, error) {
+	for _, layout := range []string{"2006-01-02 15:04:05 MST", "2006-01-02 15:04:05 -0700"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("failed to parse %s header %q", tokenExpirationHeader, value)
+}
+
+// recordFrom returns a RoundTripper that records the token expiration of
+// successful responses from upstream, which defaults to http.DefaultTransport.
+// With apps auth, requests authenticated as the app itself, like those for
+// installation tokens, are not recorded, as they are not made with the token
+// the API calls use.
+func (e *tokenExpiry) recordFrom(upstream http.RoundTripper) http.RoundTripper {
+	if upstream == nil {
+		upstream = http.DefaultTransport
+	}
+	return tokenExpiryRecorder{upstream: upstream, expiry: e}
+}
+
+type tokenExpiryRecorder struct {
+	upstream http.RoundTripper
+	expiry   *tokenExpiry
+}
+
+func (r tokenExpiryRecorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := r.upstream.RoundTrip(req)
+	if err == nil && resp.StatusCode < http.StatusBadRequest && !isAppJWTAuthenticated(req) {
+		r.expiry.record(resp.Header.Get(tokenExpirationHeader))
+	}
+	return resp, err
+}
+
+// isAppJWTAuthenticated returns whether req is authenticated with the JWT of
+// a GitHub App rather than with a token.
+func isAppJWTAuthenticated(req *http.Request) bool {
+	token := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+	// JWTs are three base64url encoded segments, the first of which is a JSON
+	// object. GitHub tokens hold no dots.
+	return strings.HasPrefix(token, "eyJ") && strings.Count(token, ".") == 2
+}
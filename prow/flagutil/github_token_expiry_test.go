@@ -0,0 +1,133 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flagutil
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTokenExpiresAt(t *testing.T) {
+	var expiration string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if expiration != "" {
+			w.Header().Set(tokenExpirationHeader, expiration)
+		}
+		fmt.Fprint(w, "{}")
+	}))
+	defer server.Close()
+
+	o := &GitHubOptions{endpoint: NewStrings(server.URL)}
+	if _, err := o.TokenExpiresAt(); err == nil {
+		t.Error("expected an error before a client was created, got none")
+	}
+	if err := o.Validate(false); err != nil {
+		t.Fatalf("failed to validate: %v", err)
+	}
+	client, err := o.GitHubClient(false)
+	if err != nil {
+		t.Fatalf("failed to construct client: %v", err)
+	}
+	if _, err := o.TokenExpiresAt(); err == nil || errors.Is(err, ErrNoExpiry) {
+		t.Errorf("expected an error before an API call was made, got %v", err)
+	}
+
+	testCases := []struct {
+		name        string
+		header      string
+		expected    time.Time
+		expectedErr error
+	}{
+		{
+			name:     "utc expiration",
+			header:   "2023-07-08 00:00:00 UTC",
+			expected: time.Date(2023, 7, 8, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "expiration with offset",
+			header:   "2023-07-08 02:00:00 +0200",
+			expected: time.Date(2023, 7, 8, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:        "token without expiration",
+			expectedErr: ErrNoExpiry,
+		},
+	}
+	for _, tc := range testCases {
+		expiration = tc.header
+		if _, err := client.GetRepo("org", "repo"); err != nil {
+			t.Fatalf("%s: failed to get repo: %v", tc.name, err)
+		}
+		expiresAt, err := o.TokenExpiresAt()
+		if !errors.Is(err, tc.expectedErr) {
+			t.Errorf("%s: expected error %v, got %v", tc.name, tc.expectedErr, err)
+		}
+		if !expiresAt.Equal(tc.expected) {
+			t.Errorf("%s: expected expiration %s, got %s", tc.name, tc.expected, expiresAt)
+		}
+	}
+}
+
+func TestTokenExpiresAtIgnoresAppRequests(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/app/installations/1/access_tokens" {
+			// Requests authenticated with the JWT of the app must not be recorded.
+			w.Header().Set(tokenExpirationHeader, "2023-01-01 00:00:00 UTC")
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprintf(w, `{"token": "ghs_expiryInstallationToken", "expires_at": %q}`, time.Now().Add(time.Hour).Format(time.RFC3339))
+			return
+		}
+		w.Header().Set(tokenExpirationHeader, "2023-07-08 00:00:00 UTC")
+		fmt.Fprint(w, `{"login": "org"}`)
+	}))
+	defer server.Close()
+
+	o := &GitHubOptions{
+		endpoint:           NewStrings(server.URL),
+		AppID:              "1",
+		AppInstallationID:  1,
+		AppPrivateKeyPaths: NewStrings(writeTestAppPrivateKey(t)),
+		DisableAppsCache:   true,
+	}
+	if err := o.Validate(false); err != nil {
+		t.Fatalf("failed to validate: %v", err)
+	}
+	client, err := o.GitHubClient(false)
+	if err != nil {
+		t.Fatalf("failed to construct client: %v", err)
+	}
+	if _, err := client.GetOrg("org"); err != nil {
+		t.Fatalf("failed to get org: %v", err)
+	}
+	generator, err := o.AppsTokenGenerator()
+	if err != nil {
+		t.Fatalf("failed to get token generator: %v", err)
+	}
+	// Fetches a new installation token, as the cache is disabled.
+	if _, err := generator("org"); err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+	expected := time.Date(2023, 7, 8, 0, 0, 0, 0, time.UTC)
+	if expiresAt, err := o.TokenExpiresAt(); err != nil || !expiresAt.Equal(expected) {
+		t.Errorf("expected expiration %s, got %s with error %v", expected, expiresAt, err)
+	}
+}
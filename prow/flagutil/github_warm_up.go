@@ -0,0 +1,69 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flagutil
+
+import (
+	"context"
+	"fmt"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+// WarmUp fetches the GitHub App installation tokens of the orgs passed
+// through WithWarmUpOrgs, or of all installations of the app if there are
+// none, so that they are cached before the component serves traffic instead
+// of being fetched by many requests at once.
+//
+// The tokens are cached by the client most recently returned by GitHubClient,
+// which also provides the tokens of git clients. If no client was constructed
+// yet, WarmUp constructs one. It does nothing unless GitHub App auth is used,
+// and must be called after Validate.
+func (o *GitHubOptions) WarmUp(ctx context.Context) error {
+	if o.AppID == "" {
+		return nil
+	}
+	tokenGenerator, _ := o.generators()
+	if tokenGenerator == nil {
+		if _, err := o.GitHubClient(false); err != nil {
+			return fmt.Errorf("error getting GitHub client: %w", err)
+		}
+		tokenGenerator, _ = o.generators()
+	}
+
+	orgs := o.warmUpOrgs
+	if len(orgs) == 0 {
+		installations, err := o.DiscoverInstallations(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to list installations of github app %s: %w", o.AppID, err)
+		}
+		for _, installation := range installations {
+			orgs = append(orgs, installation.Account.Login)
+		}
+	}
+
+	var errs []error
+	for _, org := range orgs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if _, err := tokenGenerator(org); err != nil {
+			errs = append(errs, fmt.Errorf("failed to fetch installation token for org %s: %w", org, err))
+		}
+	}
+	o.logger().WithField("orgs", len(orgs)).Info("Warmed up GitHub App installation tokens.")
+	return utilerrors.NewAggregate(errs)
+}
@@ -0,0 +1,136 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flagutil
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestWarmUp(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name                 string
+		params               []FlagParameter
+		tokenAuth            bool
+		expectedTokenFetches []string
+		warmedUpOrgs         []string
+	}{
+		{
+			name:                 "all installations",
+			expectedTokenFetches: []string{"/app/installations/7/access_tokens", "/app/installations/8/access_tokens"},
+			warmedUpOrgs:         []string{"org", "other-org"},
+		},
+		{
+			name:                 "configured orgs",
+			params:               []FlagParameter{WithWarmUpOrgs("org")},
+			expectedTokenFetches: []string{"/app/installations/7/access_tokens"},
+			warmedUpOrgs:         []string{"org"},
+		},
+		{
+			name:      "token auth",
+			tokenAuth: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			var lock sync.Mutex
+			var tokenFetches []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case r.URL.Path == "/app/installations":
+					fmt.Fprint(w, `[{"id": 7, "account": {"login": "org"}}, {"id": 8, "account": {"login": "other-org"}}]`)
+				case strings.HasSuffix(r.URL.Path, "/access_tokens"):
+					lock.Lock()
+					tokenFetches = append(tokenFetches, r.URL.Path)
+					lock.Unlock()
+					w.WriteHeader(http.StatusCreated)
+					fmt.Fprintf(w, `{"token": "warm-up-token", "expires_at": %q}`, time.Now().Add(time.Hour).Format(time.RFC3339))
+				default:
+					fmt.Fprint(w, "{}")
+				}
+			}))
+			defer server.Close()
+
+			o := &GitHubOptions{}
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			o.AddCustomizedFlags(fs, tc.params...)
+			args := []string{"--github-endpoint=" + server.URL, "--github-token-path=" + writeTestToken(t, "warm-up-pat")}
+			if !tc.tokenAuth {
+				args = []string{"--github-endpoint=" + server.URL, "--github-app-id=10", "--github-app-private-key-path=" + writeTestAppPrivateKey(t)}
+			}
+			if err := fs.Parse(args); err != nil {
+				t.Fatalf("failed to parse flags: %v", err)
+			}
+			if err := o.Validate(false); err != nil {
+				t.Fatalf("failed to validate: %v", err)
+			}
+
+			if err := o.WarmUp(context.Background()); err != nil {
+				t.Fatalf("failed to warm up: %v", err)
+			}
+			sort.Strings(tokenFetches)
+			if diff := cmp.Diff(tc.expectedTokenFetches, tokenFetches); diff != "" {
+				t.Errorf("unexpected token fetches (-want +got):\n%s", diff)
+			}
+			if tc.tokenAuth {
+				return
+			}
+
+			// The git client of the options reuses the cached tokens.
+			tokenGenerator, _ := o.generators()
+			for _, org := range tc.warmedUpOrgs {
+				if _, err := tokenGenerator(org); err != nil {
+					t.Fatalf("failed to get token: %v", err)
+				}
+			}
+			if n := len(tokenFetches); n != len(tc.expectedTokenFetches) {
+				t.Errorf("expected the warmed up tokens to be reused, got %d token fetches", n)
+			}
+		})
+	}
+}
+
+func TestWarmUpCancelled(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "{}")
+	}))
+	defer server.Close()
+
+	o := &GitHubOptions{endpoint: NewStrings(server.URL), AppID: "10", AppPrivateKeyPaths: NewStrings(writeTestAppPrivateKey(t))}
+	o.warmUpOrgs = []string{"org"}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := o.WarmUp(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
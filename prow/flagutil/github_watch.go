@@ -0,0 +1,124 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flagutil
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/sirupsen/logrus"
+	"gopkg.in/fsnotify.v1"
+)
+
+// WatchConfigAndReload watches configPath, a YAML or JSON file in the format
+// of LoadFromFile, and calls onChange with freshly loaded and validated
+// options whenever its content changes. The options it was called on are not
+// modified.
+//
+// The parent directory is watched rather than the file itself, so files that
+// are replaced through an atomic rename, like mounted ConfigMaps and Secrets,
+// are picked up too. Content that is empty or fails to parse or validate is
+// logged and skipped, as it is usually a write that is still in progress, and
+// the next change is waited for.
+//
+// Setting up the watch happens synchronously, the watching itself in the
+// background until ctx is done.
+func (o *GitHubOptions) WatchConfigAndReload(ctx context.Context, configPath string, onChange func(*GitHubOptions)) error {
+	if configPath == "" {
+		return errors.New("no config path given")
+	}
+	if onChange == nil {
+		return errors.New("no onChange callback given")
+	}
+	configPath = filepath.Clean(configPath)
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create watcher: %w", err)
+	}
+	if err := watcher.Add(filepath.Dir(configPath)); err != nil {
+		watcher.Close()
+		return fmt.Errorf("failed to watch %s: %w", filepath.Dir(configPath), err)
+	}
+	// Changes are detected by content, as a single write can result in any
+	// number of events.
+	last, _ := os.ReadFile(configPath)
+
+	logger := logrus.WithField("path", configPath)
+	go func() {
+		defer func() {
+			if err := watcher.Close(); err != nil {
+				logger.WithError(err).Error("Failed to close watcher")
+			}
+		}()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case watchErr, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				logger.WithError(watchErr).Error("GitHub config watcher errored")
+			case e, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if e.Op == fsnotify.Chmod {
+					continue
+				}
+				raw, err := os.ReadFile(configPath)
+				if err != nil {
+					// Removed or renamed away, the replacement yields another event.
+					logger.WithError(err).Debug("Failed to read GitHub config")
+					continue
+				}
+				if len(bytes.TrimSpace(raw)) == 0 || bytes.Equal(raw, last) {
+					continue
+				}
+				options, err := loadGitHubOptions(raw, configPath)
+				if err != nil {
+					logger.WithError(err).Warn("Ignoring invalid GitHub config")
+					continue
+				}
+				last = raw
+				logger.WithField("event", e.String()).Info("GitHub config changed")
+				onChange(options)
+			}
+		}
+	}()
+	return nil
+}
+
+// loadGitHubOptions returns options with their defaults, overridden by the
+// config read from path in the format of LoadFromFile, and validated.
+func loadGitHubOptions(raw []byte, path string) (*GitHubOptions, error) {
+	options := &GitHubOptions{}
+	options.addFlags(flag.NewFlagSet("github", flag.ContinueOnError))
+	if err := options.loadConfig(raw, path); err != nil {
+		return nil, err
+	}
+	if err := options.Validate(false); err != nil {
+		return nil, fmt.Errorf("invalid github config: %w", err)
+	}
+	return options, nil
+}
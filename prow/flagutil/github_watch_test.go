@@ -0,0 +1,149 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flagutil
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWatchConfigAndReload(t *testing.T) {
+	t.Parallel()
+	const (
+		initialConfig = "github-hourly-tokens: 600\ngithub-allowed-burst: 100\n"
+		changedConfig = "github-hourly-tokens: 1200\ngithub-allowed-burst: 100\n"
+	)
+	testCases := []struct {
+		name  string
+		write func(t *testing.T, path string)
+	}{
+		{
+			name: "file is written in place",
+			write: func(t *testing.T, path string) {
+				if err := os.WriteFile(path, []byte(changedConfig), 0644); err != nil {
+					t.Fatalf("failed to write config: %v", err)
+				}
+			},
+		},
+		{
+			name: "file is truncated before it is written",
+			write: func(t *testing.T, path string) {
+				f, err := os.OpenFile(path, os.O_WRONLY|os.O_TRUNC, 0644)
+				if err != nil {
+					t.Fatalf("failed to open config: %v", err)
+				}
+				defer f.Close()
+				time.Sleep(50 * time.Millisecond)
+				if _, err := f.WriteString(changedConfig); err != nil {
+					t.Fatalf("failed to write config: %v", err)
+				}
+			},
+		},
+		{
+			name: "file is replaced through an atomic rename",
+			write: func(t *testing.T, path string) {
+				tmp := filepath.Join(filepath.Dir(path), ".config.yaml.tmp")
+				if err := os.WriteFile(tmp, []byte(changedConfig), 0644); err != nil {
+					t.Fatalf("failed to write config: %v", err)
+				}
+				if err := os.Rename(tmp, path); err != nil {
+					t.Fatalf("failed to rename config: %v", err)
+				}
+			},
+		},
+		{
+			name: "invalid config is skipped",
+			write: func(t *testing.T, path string) {
+				if err := os.WriteFile(path, []byte("github-endpoint:\n- not a url\n"), 0644); err != nil {
+					t.Fatalf("failed to write config: %v", err)
+				}
+				time.Sleep(50 * time.Millisecond)
+				if err := os.WriteFile(path, []byte(changedConfig), 0644); err != nil {
+					t.Fatalf("failed to write config: %v", err)
+				}
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			if err := os.WriteFile(path, []byte(initialConfig), 0644); err != nil {
+				t.Fatalf("failed to write config: %v", err)
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			changes := make(chan *GitHubOptions, 10)
+			o := &GitHubOptions{}
+			if err := o.WatchConfigAndReload(ctx, path, func(options *GitHubOptions) { changes <- options }); err != nil {
+				t.Fatalf("failed to watch config: %v", err)
+			}
+
+			tc.write(t, path)
+			select {
+			case options := <-changes:
+				if options.ThrottleWindowTokens != 1200 {
+					t.Errorf("expected the changed config with 1200 hourly tokens, got %d", options.ThrottleWindowTokens)
+				}
+			case <-time.After(10 * time.Second):
+				t.Fatal("timed out waiting for the config to be reloaded")
+			}
+			if o.ThrottleWindowTokens != 0 {
+				t.Errorf("expected the watched options to be left alone, got %d hourly tokens", o.ThrottleWindowTokens)
+			}
+		})
+	}
+}
+
+func TestWatchConfigAndReloadErrors(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name     string
+		path     string
+		onChange func(*GitHubOptions)
+	}{
+		{
+			name:     "no path",
+			onChange: func(*GitHubOptions) {},
+		},
+		{
+			name: "no callback",
+			path: filepath.Join(os.TempDir(), "config.yaml"),
+		},
+		{
+			name:     "missing directory",
+			path:     filepath.Join(os.TempDir(), "does-not-exist", "config.yaml"),
+			onChange: func(*GitHubOptions) {},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			if err := (&GitHubOptions{}).WatchConfigAndReload(context.Background(), tc.path, tc.onChange); err == nil {
+				t.Error("expected an error, got none")
+			}
+		})
+	}
+}
@@ -70,6 +70,33 @@ func (s *Strings) Set(value string) error {
 	return nil
 }
 
+// MarshalText encodes the values joined by commas, like String, so that
+// options holding Strings round-trip through JSON and YAML. Values must not
+// contain commas to survive the round-trip.
+func (s Strings) MarshalText() ([]byte, error) {
+	return []byte(strings.Join(s.vals, ",")), nil
+}
+
+// UnmarshalText replaces the values with the comma-separated ones in text,
+// like passing each of them to the flag.
+func (s *Strings) UnmarshalText(text []byte) error {
+	s.beenSet = true
+	s.vals = nil
+	if len(text) > 0 {
+		s.vals = strings.Split(string(text), ",")
+	}
+	return nil
+}
+
+// clone returns a copy of s that does not share its values.
+func (s *Strings) clone() Strings {
+	clone := *s
+	if s.vals != nil {
+		clone.vals = append([]string(nil), s.vals...)
+	}
+	return clone
+}
+
 // Add records the value passes, adding to the defaults (if any)
 func (s *Strings) Add(value string) {
 	s.vals = append(s.vals, value)
@@ -0,0 +1,100 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flagutil
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"sigs.k8s.io/yaml"
+)
+
+func TestStringsTextRoundTrip(t *testing.T) {
+	t.Parallel()
+	type config struct {
+		Endpoints Strings `json:"endpoints"`
+	}
+	testCases := []struct {
+		name         string
+		in           Strings
+		expectedJSON string
+	}{
+		{
+			name:         "multiple values",
+			in:           NewStrings("http://ghproxy", "https://api.github.com"),
+			expectedJSON: `{"endpoints":"http://ghproxy,https://api.github.com"}`,
+		},
+		{
+			name:         "single value",
+			in:           NewStrings("http://ghproxy"),
+			expectedJSON: `{"endpoints":"http://ghproxy"}`,
+		},
+		{
+			name:         "no values",
+			in:           NewStrings(),
+			expectedJSON: `{"endpoints":""}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			raw, err := json.Marshal(config{Endpoints: tc.in})
+			if err != nil {
+				t.Fatalf("failed to marshal: %v", err)
+			}
+			if diff := cmp.Diff(tc.expectedJSON, string(raw)); diff != "" {
+				t.Errorf("unexpected JSON (-want +got):\n%s", diff)
+			}
+
+			for name, unmarshal := range map[string]func([]byte, interface{}) error{
+				"json": json.Unmarshal,
+				"yaml": func(raw []byte, into interface{}) error { return yaml.Unmarshal(raw, into) },
+			} {
+				var decoded config
+				if err := unmarshal(raw, &decoded); err != nil {
+					t.Fatalf("failed to unmarshal %s: %v", name, err)
+				}
+				if diff := cmp.Diff(tc.in.Strings(), decoded.Endpoints.Strings()); diff != "" {
+					t.Errorf("unexpected values after the %s round-trip (-want +got):\n%s", name, diff)
+				}
+				if !decoded.Endpoints.beenSet {
+					t.Errorf("expected the values decoded from %s to count as set", name)
+				}
+			}
+		})
+	}
+}
+
+func TestStringsUnmarshalTextReplacesDefaults(t *testing.T) {
+	t.Parallel()
+	s := NewStrings("https://api.github.com")
+	if err := s.UnmarshalText([]byte("http://ghproxy,http://other-ghproxy")); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+	if diff := cmp.Diff([]string{"http://ghproxy", "http://other-ghproxy"}, s.Strings()); diff != "" {
+		t.Errorf("unexpected values (-want +got):\n%s", diff)
+	}
+	if err := s.Set("http://third-ghproxy"); err != nil {
+		t.Fatalf("failed to set: %v", err)
+	}
+	if diff := cmp.Diff([]string{"http://ghproxy", "http://other-ghproxy", "http://third-ghproxy"}, s.Strings()); diff != "" {
+		t.Errorf("expected flags to add to the unmarshalled values (-want +got):\n%s", diff)
+	}
+}
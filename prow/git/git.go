@@ -60,6 +60,9 @@ type Client struct {
 	// TODO: use either base or host. the redundancy here is to help landing
 	// #14609 easier.
 	host string
+	// sshCommand is set by SetSSHKey and used as core.sshCommand when
+	// talking to the remote over SSH.
+	sshCommand string
 
 	// The mutex protects repoLocks which protect individual repos. This is
 	// necessary because Clone calls for the same repo are racy. Rather than
@@ -109,6 +112,21 @@ func (c *Client) SetRemote(remote string) {
 	c.base = remote
 }
 
+// SetSSHKey switches the client to clone from and push to the host over SSH
+// using the private key at keyPath. OAuth credentials are not used for remote
+// URLs afterwards. This is not thread-safe and should be called before the
+// client is used.
+func (c *Client) SetSSHKey(keyPath string) {
+	c.base = fmt.Sprintf("ssh://git@%s", c.host)
+	c.sshCommand = sshCommandForKey(keyPath)
+	c.SetCredentials(c.user, func(_ string) (string, error) { return "", nil })
+}
+
+// sshCommandForKey returns an ssh invocation that only offers the given key.
+func sshCommandForKey(keyPath string) string {
+	return fmt.Sprintf("ssh -i '%s' -o IdentitiesOnly=yes", strings.ReplaceAll(keyPath, "'", `'\''`))
+}
+
 type GitTokenGenerator func(org string) (string, error)
 
 // SetCredentials sets credentials in the client to be used for pushing to
@@ -174,7 +192,12 @@ func (c *Client) Clone(organization, repository string) (*Repo, error) {
 		if err := os.MkdirAll(filepath.Dir(cache), os.ModePerm); err != nil && !os.IsExist(err) {
 			return nil, err
 		}
-		if b, err := retryCmd(c.logger, "", c.git, "clone", "--mirror", remote, cache); err != nil {
+		args := []string{"clone", "--mirror"}
+		if c.sshCommand != "" {
+			args = append(args, "--config", "core.sshCommand="+c.sshCommand)
+		}
+		args = append(args, remote, cache)
+		if b, err := retryCmd(c.logger, "", c.git, args...); err != nil {
 			return nil, fmt.Errorf("git cache clone error: %v. output: %s", err, string(b))
 		}
 	} else if err != nil {
@@ -214,12 +237,18 @@ func (c *Client) Clone(organization, repository string) (*Repo, error) {
 		repo:           repository,
 		user:           user,
 		pass:           pass,
+		sshCommand:     c.sshCommand,
 		tokenGenerator: c.tokenGenerator,
 	}
 	// disable git GC
 	if err := r.Config("gc.auto", "0"); err != nil {
 		return nil, err
 	}
+	if r.sshCommand != "" {
+		if err := r.Config("core.sshCommand", r.sshCommand); err != nil {
+			return nil, err
+		}
+	}
 	return r, nil
 }
 
@@ -243,6 +272,9 @@ type Repo struct {
 	user string
 	// pass is used for pushing to the remote repo.
 	pass string
+	// sshCommand is set when the remote is accessed over SSH, in which case
+	// pass is not needed for pushing.
+	sshCommand string
 
 	// needed to generate the token.
 	tokenGenerator GitTokenGenerator
@@ -520,7 +552,7 @@ func (r *Repo) PushToNamedFork(forkName, branch string, force bool) error {
 	if err := r.refreshRepoAuth(); err != nil {
 		return err
 	}
-	if r.user == "" || r.pass == "" {
+	if r.user == "" || (r.pass == "" && r.sshCommand == "") {
 		return errors.New("cannot push without credentials - configure your git client")
 	}
 	r.logger.WithFields(logrus.Fields{"user": r.user, "repo": r.repo, "branch": branch}).Info("Pushing.")
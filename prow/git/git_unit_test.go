@@ -136,3 +136,30 @@ func TestRefreshRepoAuth(t *testing.T) {
 		})
 	}
 }
+
+func TestSetSSHKey(t *testing.T) {
+	c, err := NewClientWithHost("github.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Clean()
+	c.SetCredentials("bot", func(_ string) (string, error) { return "token", nil })
+	c.SetSSHKey("/etc/ssh key/it's-id")
+
+	if want := "ssh://git@github.example.com"; c.base != want {
+		t.Errorf("base = %q, want %q", c.base, want)
+	}
+	if want := `ssh -i '/etc/ssh key/it'\''s-id' -o IdentitiesOnly=yes`; c.sshCommand != want {
+		t.Errorf("sshCommand = %q, want %q", c.sshCommand, want)
+	}
+	user, pass, err := c.getCredentials("org")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user != "bot" || pass != "" {
+		t.Errorf("credentials = (%q, %q), want (\"bot\", \"\")", user, pass)
+	}
+	if want, got := "ssh://git@github.example.com/org/repo", remoteFromBase(c.base, user, pass, c.host, "org", "repo"); got != want {
+		t.Errorf("remote = %q, want %q", got, want)
+	}
+}
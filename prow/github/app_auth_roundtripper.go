@@ -18,7 +18,11 @@ package github
 
 import (
 	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
 	"crypto/rsa"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -30,6 +34,7 @@ import (
 	"time"
 
 	jwt "github.com/dgrijalva/jwt-go/v4"
+	"github.com/sirupsen/logrus"
 
 	"k8s.io/test-infra/ghproxy/ghcache"
 )
@@ -44,9 +49,10 @@ type appGitHubClient interface {
 	GetApp() (*App, error)
 }
 
-func newAppsRoundTripper(appID string, privateKey func() *rsa.PrivateKey, upstream http.RoundTripper, githubClient appGitHubClient, v3BaseURLs []string) (*appsRoundTripper, error) {
+func newAppsRoundTripper(appID string, installationID int64, privateKey func() crypto.Signer, upstream http.RoundTripper, githubClient appGitHubClient, v3BaseURLs []string) (*appsRoundTripper, error) {
 	roundTripper := &appsRoundTripper{
 		appID:             appID,
+		installationID:    installationID,
 		privateKey:        privateKey,
 		upstream:          upstream,
 		githubClient:      githubClient,
@@ -65,9 +71,10 @@ func newAppsRoundTripper(appID string, privateKey func() *rsa.PrivateKey, upstre
 
 type appsRoundTripper struct {
 	appID             string
+	installationID    int64
 	appSlug           string
 	appSlugLock       sync.Mutex
-	privateKey        func() *rsa.PrivateKey
+	privateKey        func() crypto.Signer
 	installationLock  sync.RWMutex
 	installations     map[string]AppInstallation
 	tokenLock         sync.RWMutex
@@ -75,6 +82,18 @@ type appsRoundTripper struct {
 	upstream          http.RoundTripper
 	githubClient      appGitHubClient
 	hostPrefixMapping map[string]string
+	// onJWTSigningError is called whenever the app JWT could not be signed.
+	onJWTSigningError func(error)
+	// onInstallationTokenFetch is called with the latency and the error of
+	// every installation token fetched from GitHub.
+	onInstallationTokenFetch func(time.Duration, error)
+	// jwtExpiry is the lifetime of the app JWT, DefaultAppJWTExpiry if zero.
+	jwtExpiry time.Duration
+	// disableTokenCache makes every request fetch a new installation token.
+	disableTokenCache bool
+	// fallbackPrivateKeys are tried in order when GitHub rejects the JWT
+	// signed with privateKey.
+	fallbackPrivateKeys []func() crypto.Signer
 }
 
 // appsAuthError is returned by the appsRoundTripper if any issues were encountered
@@ -98,16 +117,50 @@ func (arr *appsRoundTripper) RoundTrip(r *http.Request) (*http.Response, error)
 	path := arr.canonicalizedPath(r.URL)
 	// We need to use a JWT when we are getting /app/* endpoints or installation information for a particular repo
 	if strings.HasPrefix(path, "/app") || installationPath.MatchString(path) {
-		if err := arr.addAppAuth(r); err != nil {
-			return nil, err
-		}
-	} else if err := arr.addAppInstallationAuth(r); err != nil {
+		return arr.roundTripWithAppAuth(r)
+	}
+	if err := arr.addAppInstallationAuth(r); err != nil {
 		return nil, err
 	}
 
 	return arr.upstream.RoundTrip(r)
 }
 
+// roundTripWithAppAuth sends the request authenticated with a JWT. If GitHub
+// rejects the JWT and fallback keys are configured, the request is retried
+// with the next key. Every request starts with the primary key, so that a
+// single rejection does not stop it from being used.
+func (arr *appsRoundTripper) roundTripWithAppAuth(r *http.Request) (*http.Response, error) {
+	for keyIndex := 0; ; keyIndex++ {
+		if err := arr.addAppAuth(r, arr.privateKeyAt(keyIndex)); err != nil {
+			return nil, err
+		}
+		resp, err := arr.upstream.RoundTrip(r)
+		if err != nil || resp.StatusCode != http.StatusUnauthorized || keyIndex >= len(arr.fallbackPrivateKeys) {
+			return resp, err
+		}
+		if r.Body != nil && r.Body != http.NoBody {
+			if r.GetBody == nil {
+				return resp, err
+			}
+			body, err := r.GetBody()
+			if err != nil {
+				return resp, nil
+			}
+			r.Body = body
+		}
+		resp.Body.Close()
+		logrus.WithField("key-index", keyIndex+1).Warn("GitHub rejected the app JWT, retrying with the next private key.")
+	}
+}
+
+func (arr *appsRoundTripper) privateKeyAt(index int) crypto.Signer {
+	if index == 0 {
+		return arr.privateKey()
+	}
+	return arr.fallbackPrivateKeys[index-1]()
+}
+
 // TimeNow is exposed so that it can be mocked by unit test, to ensure that
 // addAppAuth always return consistent token when needed.
 // DO NOT use it in prod
@@ -115,15 +168,18 @@ var TimeNow = func() time.Time {
 	return time.Now().UTC()
 }
 
-func (arr *appsRoundTripper) addAppAuth(r *http.Request) *appsAuthError {
+func (arr *appsRoundTripper) addAppAuth(r *http.Request, privateKey crypto.Signer) *appsAuthError {
 	now := TimeNow()
-	expiresAt := now.Add(10 * time.Minute)
-	token, err := jwt.NewWithClaims(jwt.SigningMethodRS256, &jwt.StandardClaims{
-		IssuedAt:  jwt.NewTime(float64(now.Unix())),
-		ExpiresAt: jwt.NewTime(float64(expiresAt.Unix())),
-		Issuer:    arr.appID,
-	}).SignedString(arr.privateKey())
+	expiry := arr.jwtExpiry
+	if expiry == 0 {
+		expiry = DefaultAppJWTExpiry
+	}
+	expiresAt := now.Add(expiry)
+	token, err := arr.signJWT(privateKey, now, expiresAt)
 	if err != nil {
+		if arr.onJWTSigningError != nil {
+			arr.onJWTSigningError(err)
+		}
 		return &appsAuthError{fmt.Errorf("failed to generate jwt: %w", err)}
 	}
 
@@ -143,6 +199,61 @@ func (arr *appsRoundTripper) addAppAuth(r *http.Request) *appsAuthError {
 	return nil
 }
 
+func (arr *appsRoundTripper) signJWT(privateKey crypto.Signer, issuedAt, expiresAt time.Time) (string, error) {
+	signingMethod, err := signingMethodFor(privateKey)
+	if err != nil {
+		return "", err
+	}
+	return jwt.NewWithClaims(signingMethod, &jwt.StandardClaims{
+		IssuedAt:  jwt.NewTime(float64(issuedAt.Unix())),
+		ExpiresAt: jwt.NewTime(float64(expiresAt.Unix())),
+		Issuer:    arr.appID,
+	}).SignedString(privateKey)
+}
+
+// signingMethodFor returns the JWT signing method to use with the given key.
+func signingMethodFor(key crypto.Signer) (jwt.SigningMethod, error) {
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		return jwt.SigningMethodRS256, nil
+	case *ecdsa.PrivateKey:
+		if k.Curve != elliptic.P256() {
+			return nil, fmt.Errorf("unsupported ecdsa curve %s, only P-256 is supported", k.Curve.Params().Name)
+		}
+		return jwt.SigningMethodES256, nil
+	default:
+		return nil, fmt.Errorf("unsupported private key type %T", key)
+	}
+}
+
+// AppJWTAlgorithm returns the algorithm of the JWTs that are signed with the
+// given private key of a GitHub App: RS256 for RSA keys and ES256 for ECDSA
+// P-256 keys.
+func AppJWTAlgorithm(key crypto.Signer) (string, error) {
+	signingMethod, err := signingMethodFor(key)
+	if err != nil {
+		return "", err
+	}
+	return signingMethod.Alg(), nil
+}
+
+// ParseAppPrivateKeyFromPEM parses the private key of a GitHub App. Both RSA and
+// ECDSA P-256 keys are supported.
+func ParseAppPrivateKeyFromPEM(raw []byte) (crypto.Signer, error) {
+	rsaKey, rsaErr := jwt.ParseRSAPrivateKeyFromPEM(raw)
+	if rsaErr == nil {
+		return rsaKey, nil
+	}
+	ecdsaKey, ecdsaErr := jwt.ParseECPrivateKeyFromPEM(raw)
+	if ecdsaErr == nil {
+		if _, err := signingMethodFor(ecdsaKey); err != nil {
+			return nil, err
+		}
+		return ecdsaKey, nil
+	}
+	return nil, fmt.Errorf("key is neither a valid rsa key nor a valid ecdsa key: %w", errors.Join(rsaErr, ecdsaErr))
+}
+
 func extractOrgFromContext(ctx context.Context) string {
 	var org string
 	if v := ctx.Value(githubOrgHeaderKey); v != nil {
@@ -153,7 +264,7 @@ func extractOrgFromContext(ctx context.Context) string {
 
 func (arr *appsRoundTripper) addAppInstallationAuth(r *http.Request) *appsAuthError {
 	org := extractOrgFromContext(r.Context())
-	if org == "" {
+	if org == "" && arr.installationID == 0 {
 		return &appsAuthError{fmt.Errorf("BUG apps auth requested but empty org, please report this to the test-infra repo. Stack: %s", string(debug.Stack()))}
 	}
 
@@ -177,9 +288,13 @@ func (arr *appsRoundTripper) addAppInstallationAuth(r *http.Request) *appsAuthEr
 }
 
 func (arr *appsRoundTripper) installationTokenFor(org string) (string, time.Time, error) {
-	installationID, err := arr.installationIDFor(org)
-	if err != nil {
-		return "", time.Time{}, fmt.Errorf("failed to get installation id for org %s: %w", org, err)
+	installationID := arr.installationID
+	if installationID == 0 {
+		var err error
+		installationID, err = arr.installationIDFor(org)
+		if err != nil {
+			return "", time.Time{}, fmt.Errorf("failed to get installation id for org %s: %w", org, err)
+		}
 	}
 
 	token, expiresAt, err := arr.getTokenForInstallation(installationID)
@@ -236,6 +351,14 @@ func (arr *appsRoundTripper) installationIDFor(org string) (int64, error) {
 }
 
 func (arr *appsRoundTripper) getTokenForInstallation(installation int64) (string, time.Time, error) {
+	if arr.disableTokenCache {
+		token, err := arr.fetchInstallationToken(installation)
+		if err != nil {
+			return "", time.Time{}, fmt.Errorf("failed to get installation token from GitHub: %w", err)
+		}
+		return token.Token, token.ExpiresAt, nil
+	}
+
 	arr.tokenLock.RLock()
 	token, found := arr.tokens[installation]
 	arr.tokenLock.RUnlock()
@@ -253,7 +376,7 @@ func (arr *appsRoundTripper) getTokenForInstallation(installation int64) (string
 		return token.Token, token.ExpiresAt, nil
 	}
 
-	token, err := arr.githubClient.getAppInstallationToken(installation)
+	token, err := arr.fetchInstallationToken(installation)
 	if err != nil {
 		return "", time.Time{}, fmt.Errorf("failed to get installation token from GitHub: %w", err)
 	}
@@ -266,6 +389,16 @@ func (arr *appsRoundTripper) getTokenForInstallation(installation int64) (string
 	return token.Token, token.ExpiresAt, nil
 }
 
+// fetchInstallationToken gets a new token for the installation from GitHub.
+func (arr *appsRoundTripper) fetchInstallationToken(installation int64) (*AppInstallationToken, error) {
+	start := time.Now()
+	token, err := arr.githubClient.getAppInstallationToken(installation)
+	if arr.onInstallationTokenFetch != nil {
+		arr.onInstallationTokenFetch(time.Since(start), err)
+	}
+	return token, err
+}
+
 func (arr *appsRoundTripper) getSlug() (string, error) {
 	arr.appSlugLock.Lock()
 	defer arr.appSlugLock.Unlock()
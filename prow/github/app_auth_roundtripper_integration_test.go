@@ -17,11 +17,10 @@ limitations under the License.
 package github
 
 import (
-	"crypto/rsa"
+	"crypto"
 	"os"
 	"testing"
 
-	"github.com/dgrijalva/jwt-go/v4"
 	"github.com/sirupsen/logrus"
 )
 
@@ -37,7 +36,7 @@ func TestGetOrg(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Failed to read private key: %v", err)
 	}
-	key, err := jwt.ParseRSAPrivateKeyFromPEM(keyData)
+	key, err := ParseAppPrivateKeyFromPEM(keyData)
 	if err != nil {
 		t.Fatalf("Failed to parse key: %v", err)
 	}
@@ -46,7 +45,7 @@ func TestGetOrg(t *testing.T) {
 		logrus.Fields{},
 		func(b []byte) []byte { return b },
 		appID,
-		func() *rsa.PrivateKey { return key },
+		func() crypto.Signer { return key },
 		"https://api.github.com/graphql",
 		"http://localhost:8888",
 	)
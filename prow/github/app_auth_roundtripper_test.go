@@ -18,9 +18,14 @@ package github
 
 import (
 	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
+	"crypto/x509"
 	"encoding/json"
+	"encoding/pem"
 	"fmt"
 	"io"
 	"net/http"
@@ -29,6 +34,7 @@ import (
 	"testing"
 	"time"
 
+	jwt "github.com/dgrijalva/jwt-go/v4"
 	"github.com/sirupsen/logrus"
 
 	utilpointer "k8s.io/utils/pointer"
@@ -66,6 +72,7 @@ func TestAppsAuth(t *testing.T) {
 		cachedAppSlug       *string
 		cachedInstallations map[string]AppInstallation
 		cachedTokens        map[int64]*AppInstallationToken
+		installationID      int64
 		doRequest           func(Client) error
 		responses           map[string]*http.Response
 		verifyRequests      func([]*http.Request) error
@@ -119,6 +126,29 @@ func TestAppsAuth(t *testing.T) {
 				return nil
 			},
 		},
+		{
+			name:           "App installation auth with pinned installation, org lookup is skipped",
+			cachedAppSlug:  utilpointer.String("ci-app"),
+			cachedTokens:   map[int64]*AppInstallationToken{5: {Token: "the-token", ExpiresAt: time.Now().Add(time.Hour)}},
+			installationID: 5,
+			doRequest: func(c Client) error {
+				_, err := c.GetOrg("org")
+				return err
+			},
+			responses: map[string]*http.Response{"/orgs/org": {
+				StatusCode: 200,
+				Body:       serializeOrDie(Organization{}),
+			}},
+			verifyRequests: func(r []*http.Request) error {
+				if n := len(r); n != 1 {
+					return fmt.Errorf("expected exactly one request, got %d", n)
+				}
+				if val := r[0].Header.Get("Authorization"); val != "Bearer the-token" {
+					return fmt.Errorf("expected the Authorization header %q to be 'Bearer the-token'", val)
+				}
+				return nil
+			},
+		},
 		{
 			name:                "App installation auth success, everything served from cache",
 			cachedAppSlug:       utilpointer.String("ci-app"),
@@ -411,7 +441,7 @@ func TestAppsAuth(t *testing.T) {
 			if tc.githubBaseURL == "" {
 				tc.githubBaseURL = "https://api.github.com"
 			}
-			_, _, ghClient, err := NewAppsAuthClientWithFields(logrus.Fields{}, func(b []byte) []byte { return b }, appID, func() *rsa.PrivateKey { return rsaKey }, "", tc.githubBaseURL)
+			_, _, ghClient, err := NewAppsAuthClientWithFields(logrus.Fields{}, func(b []byte) []byte { return b }, appID, func() crypto.Signer { return rsaKey }, "", tc.githubBaseURL)
 			if err != nil {
 				t.Fatalf("failed to construct client: %v", err)
 			}
@@ -431,6 +461,7 @@ func TestAppsAuth(t *testing.T) {
 			if tc.cachedTokens != nil {
 				appsRoundTripper.tokens = tc.cachedTokens
 			}
+			appsRoundTripper.installationID = tc.installationID
 
 			if err := tc.doRequest(ghClient); err != nil {
 				t.Fatalf("Failed to do request: %v", err)
@@ -468,7 +499,7 @@ func TestAppsRoundTripperThreadSafety(t *testing.T) {
 		t.Fatalf("Failed to generate RSA key: %v", err)
 	}
 
-	_, _, ghClient, err := NewAppsAuthClientWithFields(logrus.Fields{}, nil, appID, func() *rsa.PrivateKey { return rsaKey }, "", "https://api.github.com")
+	_, _, ghClient, err := NewAppsAuthClientWithFields(logrus.Fields{}, nil, appID, func() crypto.Signer { return rsaKey }, "", "https://api.github.com")
 	if err != nil {
 		t.Fatalf("failed to construct github client: %v", err)
 	}
@@ -511,6 +542,327 @@ func TestAppsRoundTripperThreadSafety(t *testing.T) {
 	<-req2Done
 }
 
+func TestAppsAuthWithECDSAKey(t *testing.T) {
+	const appID = "13"
+	ecdsaKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate ECDSA key: %v", err)
+	}
+
+	_, _, ghClient, err := NewAppsAuthClientWithFields(logrus.Fields{}, nil, appID, func() crypto.Signer { return ecdsaKey }, "", "https://api.github.com")
+	if err != nil {
+		t.Fatalf("failed to construct github client: %v", err)
+	}
+	roundTripper := &fakeRoundTripper{
+		responses: map[string]*http.Response{"/app": {StatusCode: 200, Body: serializeOrDie(App{})}},
+	}
+	validateAppsRoundTripper(t, ghClient).upstream = roundTripper
+
+	if _, err := ghClient.GetApp(); err != nil {
+		t.Fatalf("Failed to do request: %v", err)
+	}
+	if n := len(roundTripper.requests); n != 1 {
+		t.Fatalf("expected exactly one request, got %d", n)
+	}
+	raw := strings.TrimPrefix(roundTripper.requests[0].Header.Get("Authorization"), "Bearer ")
+	token, err := jwt.Parse(raw, func(*jwt.Token) (interface{}, error) { return &ecdsaKey.PublicKey, nil })
+	if err != nil {
+		t.Fatalf("failed to verify jwt: %v", err)
+	}
+	if alg := token.Method.Alg(); alg != "ES256" {
+		t.Errorf("expected jwt to be signed with ES256, got %s", alg)
+	}
+}
+
+type keyVerifyingRoundTripper struct {
+	publicKey *rsa.PublicKey
+	requests  int
+}
+
+func (rt *keyVerifyingRoundTripper) RoundTrip(r *http.Request) (*http.Response, error) {
+	rt.requests++
+	raw := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if _, err := jwt.Parse(raw, func(*jwt.Token) (interface{}, error) { return rt.publicKey, nil }); err != nil {
+		return &http.Response{StatusCode: http.StatusUnauthorized, Body: io.NopCloser(&bytes.Buffer{})}, nil
+	}
+	return &http.Response{StatusCode: http.StatusOK, Body: serializeOrDie(App{Slug: "app"})}, nil
+}
+
+func TestAppsAuthFallbackPrivateKeys(t *testing.T) {
+	primaryKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatalf("Failed to generate RSA key: %v", err)
+	}
+	fallbackKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatalf("Failed to generate RSA key: %v", err)
+	}
+
+	_, _, ghClient, err := NewClientFromOptions(logrus.Fields{}, ClientOptions{
+		AppID:                  "13",
+		AppPrivateKey:          func() crypto.Signer { return primaryKey },
+		AppFallbackPrivateKeys: []func() crypto.Signer{func() crypto.Signer { return fallbackKey }},
+		Bases:                  []string{"https://api.github.com"},
+	})
+	if err != nil {
+		t.Fatalf("failed to construct github client: %v", err)
+	}
+	roundTripper := &keyVerifyingRoundTripper{publicKey: &fallbackKey.PublicKey}
+	validateAppsRoundTripper(t, ghClient).upstream = roundTripper
+
+	if _, err := ghClient.GetApp(); err != nil {
+		t.Fatalf("Failed to do request: %v", err)
+	}
+	if roundTripper.requests != 2 {
+		t.Errorf("expected the request to be retried with the fallback key, got %d requests", roundTripper.requests)
+	}
+	if _, err := ghClient.GetApp(); err != nil {
+		t.Fatalf("Failed to do request: %v", err)
+	}
+	if roundTripper.requests != 4 {
+		t.Errorf("expected the next request to try the primary key first, got %d requests in total", roundTripper.requests)
+	}
+
+	// Once GitHub accepts the primary key again, it is used right away.
+	roundTripper.publicKey = &primaryKey.PublicKey
+	if _, err := ghClient.GetApp(); err != nil {
+		t.Fatalf("Failed to do request: %v", err)
+	}
+	if roundTripper.requests != 5 {
+		t.Errorf("expected the primary key to be used again, got %d requests in total", roundTripper.requests)
+	}
+}
+
+func TestAppsAuthJWTSigningErrorHook(t *testing.T) {
+	p384Key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate ECDSA key: %v", err)
+	}
+
+	var signingErrors int
+	_, _, ghClient, err := NewClientFromOptions(logrus.Fields{}, ClientOptions{
+		AppID:                "13",
+		AppPrivateKey:        func() crypto.Signer { return p384Key },
+		Bases:                []string{"https://api.github.com"},
+		OnAppJWTSigningError: func(error) { signingErrors++ },
+	})
+	if err != nil {
+		t.Fatalf("failed to construct github client: %v", err)
+	}
+	validateAppsRoundTripper(t, ghClient).upstream = &fakeRoundTripper{}
+
+	if _, err := ghClient.GetApp(); err == nil {
+		t.Fatal("expected an error signing with a P-384 key, got none")
+	}
+	if signingErrors != 1 {
+		t.Errorf("expected the hook to be called once, got %d", signingErrors)
+	}
+}
+
+func TestAppsAuthJWTExpiry(t *testing.T) {
+	ecdsaKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate ECDSA key: %v", err)
+	}
+
+	testCases := []struct {
+		name           string
+		expiry         time.Duration
+		expectedExpiry time.Duration
+	}{
+		{
+			name:           "default",
+			expectedExpiry: DefaultAppJWTExpiry,
+		},
+		{
+			name:           "configured",
+			expiry:         5 * time.Minute,
+			expectedExpiry: 5 * time.Minute,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, _, ghClient, err := NewClientFromOptions(logrus.Fields{}, ClientOptions{
+				AppID:         "13",
+				AppPrivateKey: func() crypto.Signer { return ecdsaKey },
+				AppJWTExpiry:  tc.expiry,
+				Bases:         []string{"https://api.github.com"},
+			})
+			if err != nil {
+				t.Fatalf("failed to construct github client: %v", err)
+			}
+			roundTripper := &fakeRoundTripper{
+				responses: map[string]*http.Response{"/app": {StatusCode: 200, Body: serializeOrDie(App{})}},
+			}
+			validateAppsRoundTripper(t, ghClient).upstream = roundTripper
+
+			if _, err := ghClient.GetApp(); err != nil {
+				t.Fatalf("Failed to do request: %v", err)
+			}
+			raw := strings.TrimPrefix(roundTripper.requests[0].Header.Get("Authorization"), "Bearer ")
+			claims := &jwt.StandardClaims{}
+			if _, err := jwt.ParseWithClaims(raw, claims, func(*jwt.Token) (interface{}, error) { return &ecdsaKey.PublicKey, nil }); err != nil {
+				t.Fatalf("failed to verify jwt: %v", err)
+			}
+			if expiry := claims.ExpiresAt.Sub(claims.IssuedAt.Time); expiry != tc.expectedExpiry {
+				t.Errorf("expected the jwt to expire after %s, got %s", tc.expectedExpiry, expiry)
+			}
+		})
+	}
+}
+
+// tokenCountingRoundTripper answers the requests of an app pinned to
+// installation 1 and counts the installation tokens it hands out.
+type tokenCountingRoundTripper struct {
+	lock   sync.Mutex
+	tokens int
+}
+
+func (rt *tokenCountingRoundTripper) RoundTrip(r *http.Request) (*http.Response, error) {
+	rt.lock.Lock()
+	defer rt.lock.Unlock()
+	switch r.URL.Path {
+	case "/app/installations/1/access_tokens":
+		rt.tokens++
+		return &http.Response{StatusCode: 201, Body: serializeOrDie(AppInstallationToken{Token: fmt.Sprintf("token-%d", rt.tokens), ExpiresAt: time.Now().Add(time.Hour)})}, nil
+	case "/app":
+		return &http.Response{StatusCode: 200, Body: serializeOrDie(App{Slug: "app"})}, nil
+	default:
+		return &http.Response{StatusCode: 200, Body: serializeOrDie(Organization{})}, nil
+	}
+}
+
+func TestAppsAuthDisableTokenCache(t *testing.T) {
+	ecdsaKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate ECDSA key: %v", err)
+	}
+
+	testCases := []struct {
+		name           string
+		disableCache   bool
+		expectedTokens int
+	}{
+		{
+			name:           "tokens are cached",
+			expectedTokens: 1,
+		},
+		{
+			name:           "tokens are fetched for every request",
+			disableCache:   true,
+			expectedTokens: 3,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var fetches int
+			_, _, ghClient, err := NewClientFromOptions(logrus.Fields{}, ClientOptions{
+				AppID:                "13",
+				AppInstallationID:    1,
+				AppPrivateKey:        func() crypto.Signer { return ecdsaKey },
+				DisableAppTokenCache: tc.disableCache,
+				Bases:                []string{"https://api.github.com"},
+				OnAppInstallationTokenFetch: func(_ time.Duration, err error) {
+					if err != nil {
+						t.Errorf("unexpected error fetching installation token: %v", err)
+					}
+					fetches++
+				},
+			})
+			if err != nil {
+				t.Fatalf("failed to construct github client: %v", err)
+			}
+			roundTripper := &tokenCountingRoundTripper{}
+			validateAppsRoundTripper(t, ghClient).upstream = roundTripper
+
+			for i := 0; i < 3; i++ {
+				if _, err := ghClient.GetOrg("org"); err != nil {
+					t.Fatalf("Failed to do request: %v", err)
+				}
+			}
+			if roundTripper.tokens != tc.expectedTokens {
+				t.Errorf("expected %d installation tokens to be fetched, got %d", tc.expectedTokens, roundTripper.tokens)
+			}
+			if fetches != tc.expectedTokens {
+				t.Errorf("expected the fetch hook to be called %d times, got %d", tc.expectedTokens, fetches)
+			}
+		})
+	}
+}
+
+func TestParseAppPrivateKeyFromPEM(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 512)
+	if err != nil {
+		t.Fatalf("Failed to generate RSA key: %v", err)
+	}
+	p256Key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate ECDSA key: %v", err)
+	}
+	p384Key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate ECDSA key: %v", err)
+	}
+	encodeECDSA := func(key *ecdsa.PrivateKey) []byte {
+		raw, err := x509.MarshalECPrivateKey(key)
+		if err != nil {
+			t.Fatalf("Failed to marshal ECDSA key: %v", err)
+		}
+		return pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: raw})
+	}
+
+	testCases := []struct {
+		name              string
+		raw               []byte
+		expected          crypto.Signer
+		expectedAlgorithm string
+		expectedErr       bool
+	}{
+		{
+			name:              "rsa key",
+			raw:               pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)}),
+			expected:          rsaKey,
+			expectedAlgorithm: "RS256",
+		},
+		{
+			name:              "ecdsa P-256 key",
+			raw:               encodeECDSA(p256Key),
+			expected:          p256Key,
+			expectedAlgorithm: "ES256",
+		},
+		{
+			name:        "ecdsa P-384 key is not supported",
+			raw:         encodeECDSA(p384Key),
+			expectedErr: true,
+		},
+		{
+			name:        "garbage",
+			raw:         []byte("not a key"),
+			expectedErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			key, err := ParseAppPrivateKeyFromPEM(tc.raw)
+			if (err != nil) != tc.expectedErr {
+				t.Fatalf("expected error: %t, got: %v", tc.expectedErr, err)
+			}
+			if err != nil {
+				return
+			}
+			if !tc.expected.(interface{ Equal(crypto.PrivateKey) bool }).Equal(key) {
+				t.Error("parsed key does not match the expected one")
+			}
+			if algorithm, err := AppJWTAlgorithm(key); err != nil || algorithm != tc.expectedAlgorithm {
+				t.Errorf("expected algorithm %s, got %s and error %v", tc.expectedAlgorithm, algorithm, err)
+			}
+		})
+	}
+}
+
 func serializeOrDie(in interface{}) io.ReadCloser {
 	rawData, err := json.Marshal(in)
 	if err != nil {
@@ -19,13 +19,14 @@ package github
 import (
 	"bytes"
 	"context"
-	"crypto/rsa"
+	"crypto"
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"math/rand"
 	"net/http"
 	"net/url"
 	"regexp"
@@ -312,15 +313,20 @@ type delegate struct {
 	max404Retries int
 	maxSleepTime  time.Duration
 	initialDelay  time.Duration
+	callTimeout   time.Duration
 
 	client       httpClient
 	bases        []string
+	baseWeights  []int
 	dry          bool
 	fake         bool
 	usesAppsAuth bool
 	throttle     ghThrottler
 	getToken     func() []byte
 	censor       func([]byte) []byte
+	acceptHeader string
+	// baseUserAgent replaces the default User-Agent of the component if set.
+	baseUserAgent string
 
 	mut      sync.Mutex // protects botName and email
 	userData *UserData
@@ -360,6 +366,12 @@ func (c *client) forKeyValue(key, value string) Client {
 }
 
 func (c *client) userAgent() string {
+	if c.baseUserAgent != "" {
+		if c.identifier != "" {
+			return fmt.Sprintf("%s (%s)", c.baseUserAgent, c.identifier)
+		}
+		return c.baseUserAgent
+	}
 	if c.identifier != "" {
 		return version.UserAgentWithIdentifier(c.identifier)
 	}
@@ -394,6 +406,10 @@ const (
 	DefaultMax404Retries = 2
 	DefaultMaxSleepTime  = 2 * time.Minute
 	DefaultInitialDelay  = 2 * time.Second
+
+	// DefaultAppJWTExpiry is the lifetime of the JWTs used for apps auth,
+	// which is the maximum GitHub accepts.
+	DefaultAppJWTExpiry = 10 * time.Minute
 )
 
 // Force the compiler to check if the TokenSource is implementing correctly.
@@ -502,15 +518,51 @@ type ClientOptions struct {
 	// the following fields handle auth
 	GetToken      func() []byte
 	AppID         string
-	AppPrivateKey func() *rsa.PrivateKey
+	AppPrivateKey func() crypto.Signer
+	// AppInstallationID pins all requests to the given installation instead
+	// of resolving the installation from the org of each request.
+	AppInstallationID int64
+	// AppFallbackPrivateKeys are tried in order when GitHub rejects the JWT
+	// signed with AppPrivateKey, e.g. during a key rotation. Optional.
+	AppFallbackPrivateKeys []func() crypto.Signer
+	// OnAppJWTSigningError is called whenever the JWT used for apps auth
+	// could not be signed. Optional.
+	OnAppJWTSigningError func(error)
+	// OnAppInstallationTokenFetch is called with the latency and the error of
+	// every installation token apps auth fetches from GitHub. Optional.
+	OnAppInstallationTokenFetch func(time.Duration, error)
+	// AppJWTExpiry is the lifetime of the JWTs used for apps auth. Defaults
+	// to DefaultAppJWTExpiry.
+	AppJWTExpiry time.Duration
+	// DisableAppTokenCache makes apps auth fetch a new installation token for
+	// every request instead of reusing it until shortly before it expires.
+	// This is meant for debugging token issues only.
+	DisableAppTokenCache bool
 
 	// the following fields determine which server we talk to
 	GraphqlEndpoint string
 	Bases           []string
+	// BaseWeights distributes requests over the Bases at random, each base
+	// getting a share of the requests proportional to its weight. Requests
+	// still fail over to the next base on connection problems. It must be
+	// empty, which sends all requests to the first base, or have a
+	// non-negative weight for every base, not all of them zero.
+	BaseWeights []int
+	// AcceptHeader is the Accept header of REST API requests that do not need
+	// a specific media type. Defaults to DefaultAcceptHeader.
+	AcceptHeader string
+	// UserAgent is sent as the User-Agent header of all requests. Defaults to
+	// the name and version of the component, see version.UserAgent.
+	UserAgent string
 
 	// the following fields determine client retry behavior
 	MaxRequestTime, InitialDelay, MaxSleepTime time.Duration
 	MaxRetries, Max404Retries                  int
+	// CallTimeout bounds each REST API call of the client including its
+	// retries, backoff and, for lists, all pages, unlike MaxRequestTime, which
+	// applies to every single HTTP request. Zero means no bound beyond the
+	// context passed to the call.
+	CallTimeout time.Duration
 
 	DryRun bool
 	// BaseRoundTripper is the last RoundTripper to be called. Used for testing, gets defaulted to http.DefaultTransport
@@ -533,6 +585,12 @@ func (o ClientOptions) Default() ClientOptions {
 	if o.Max404Retries == 0 {
 		o.Max404Retries = DefaultMax404Retries
 	}
+	if o.AcceptHeader == "" {
+		o.AcceptHeader = DefaultAcceptHeader
+	}
+	if o.AppJWTExpiry == 0 {
+		o.AppJWTExpiry = DefaultAppJWTExpiry
+	}
 	return o
 }
 
@@ -561,7 +619,7 @@ func NewClientWithFields(fields logrus.Fields, getToken func() []byte, censor fu
 	return client, err
 }
 
-func NewAppsAuthClientWithFields(fields logrus.Fields, censor func([]byte) []byte, appID string, appPrivateKey func() *rsa.PrivateKey, graphqlEndpoint string, bases ...string) (TokenGenerator, UserGenerator, Client, error) {
+func NewAppsAuthClientWithFields(fields logrus.Fields, censor func([]byte) []byte, appID string, appPrivateKey func() crypto.Signer, graphqlEndpoint string, bases ...string) (TokenGenerator, UserGenerator, Client, error) {
 	return NewClientFromOptions(fields, ClientOptions{
 		Censor:          censor,
 		AppID:           appID,
@@ -612,6 +670,7 @@ func NewClientFromOptions(fields logrus.Fields, options ClientOptions) (TokenGen
 			time:          &standardTime{},
 			client:        httpClient,
 			bases:         options.Bases,
+			baseWeights:   options.BaseWeights,
 			throttle:      ghThrottler{Throttler: &throttle.Throttler{}},
 			getToken:      options.GetToken,
 			censor:        options.Censor,
@@ -620,7 +679,10 @@ func NewClientFromOptions(fields logrus.Fields, options ClientOptions) (TokenGen
 			maxRetries:    options.MaxRetries,
 			max404Retries: options.Max404Retries,
 			initialDelay:  options.InitialDelay,
+			callTimeout:   options.CallTimeout,
 			maxSleepTime:  options.MaxSleepTime,
+			acceptHeader:  options.AcceptHeader,
+			baseUserAgent: options.UserAgent,
 		},
 	}
 	c.gqlc = c.gqlc.forUserAgent(c.userAgent())
@@ -631,10 +693,15 @@ func NewClientFromOptions(fields logrus.Fields, options ClientOptions) (TokenGen
 	var tokenGenerator func(_ string) (string, error)
 	var userGenerator func() (string, error)
 	if options.AppID != "" {
-		appsTransport, err := newAppsRoundTripper(options.AppID, options.AppPrivateKey, options.BaseRoundTripper, c, options.Bases)
+		appsTransport, err := newAppsRoundTripper(options.AppID, options.AppInstallationID, options.AppPrivateKey, options.BaseRoundTripper, c, options.Bases)
 		if err != nil {
 			return nil, nil, nil, fmt.Errorf("failed to construct apps auth roundtripper: %w", err)
 		}
+		appsTransport.onJWTSigningError = options.OnAppJWTSigningError
+		appsTransport.onInstallationTokenFetch = options.OnAppInstallationTokenFetch
+		appsTransport.fallbackPrivateKeys = options.AppFallbackPrivateKeys
+		appsTransport.jwtExpiry = options.AppJWTExpiry
+		appsTransport.disableTokenCache = options.DisableAppTokenCache
 		httpClient.Transport = appsTransport
 		graphQLTransport.upstream = appsTransport
 
@@ -747,7 +814,7 @@ func NewDryRunClientWithFields(fields logrus.Fields, getToken func() []byte, cen
 // NewAppsAuthDryRunClientWithFields creates a new client that will not perform mutating actions
 // such as setting statuses or commenting, but it will still query GitHub and
 // use up API tokens. Additional fields are added to the logger.
-func NewAppsAuthDryRunClientWithFields(fields logrus.Fields, censor func([]byte) []byte, appId string, appPrivateKey func() *rsa.PrivateKey, graphqlEndpoint string, bases ...string) (TokenGenerator, UserGenerator, Client, error) {
+func NewAppsAuthDryRunClientWithFields(fields logrus.Fields, censor func([]byte) []byte, appId string, appPrivateKey func() crypto.Signer, graphqlEndpoint string, bases ...string) (TokenGenerator, UserGenerator, Client, error) {
 	return NewClientFromOptions(fields, ClientOptions{
 		Censor:          censor,
 		AppID:           appId,
@@ -838,6 +905,16 @@ func (r requestError) ErrorMessages() []string {
 	return []string{}
 }
 
+// IsRetryable returns whether err is the response of a request that failed
+// with a 429 or 5XX status, which may succeed when retried later.
+func IsRetryable(err error) bool {
+	var requestErr requestError
+	if !errors.As(err, &requestErr) {
+		return false
+	}
+	return requestErr.StatusCode == http.StatusTooManyRequests || requestErr.StatusCode >= 500
+}
+
 // NewNotFound returns a NotFound error which may be useful for tests
 func NewNotFound() error {
 	return requestError{
@@ -898,6 +975,8 @@ func (c *client) requestRawWithContext(ctx context.Context, r *request) (int, []
 	if c.fake || (c.dry && r.method != http.MethodGet) {
 		return r.exitCodes[0], nil, nil
 	}
+	ctx, cancel := c.withCallTimeout(ctx)
+	defer cancel()
 	resp, err := c.requestRetryWithContext(ctx, r.method, r.path, r.accept, r.org, r.requestBody)
 	if err != nil {
 		return 0, nil, err
@@ -925,6 +1004,38 @@ func (c *client) requestRawWithContext(ctx context.Context, r *request) (int, []
 	return resp.StatusCode, b, err
 }
 
+// withCallTimeout bounds ctx by the callTimeout if it is set. The returned
+// function must be called once the call is done, including reading the body.
+func (d *delegate) withCallTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if d.callTimeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, d.callTimeout)
+}
+
+// firstHostIndex returns the index of the base a request is sent to first,
+// picked at random by the baseWeights if they are set.
+func (d *delegate) firstHostIndex() int {
+	if len(d.baseWeights) != len(d.bases) {
+		return 0
+	}
+	var total int
+	for _, weight := range d.baseWeights {
+		total += weight
+	}
+	if total <= 0 {
+		return 0
+	}
+	pick := rand.Intn(total)
+	for i, weight := range d.baseWeights {
+		if pick < weight {
+			return i
+		}
+		pick -= weight
+	}
+	return 0
+}
+
 // Retry on transport failures. Retries on 500s, retries after sleep on
 // ratelimit exceeded, and retries 404s a couple times.
 // This function closes the response body iff it also returns an error.
@@ -933,7 +1044,7 @@ func (c *client) requestRetry(method, path, accept, org string, body interface{}
 }
 
 func (c *client) requestRetryWithContext(ctx context.Context, method, path, accept, org string, body interface{}) (*http.Response, error) {
-	var hostIndex int
+	hostIndex := c.firstHostIndex()
 	var resp *http.Response
 	var err error
 	backoff := c.initialDelay
@@ -1075,10 +1186,12 @@ func (c *client) doRequest(ctx context.Context, method, path, accept, org string
 		req.Header.Set("Authorization", header)
 	}
 	if accept == acceptNone {
-		req.Header.Add("Accept", "application/vnd.github.v3+json")
-	} else {
-		req.Header.Add("Accept", accept)
+		accept = c.acceptHeader
 	}
+	if accept == acceptNone {
+		accept = DefaultAcceptHeader
+	}
+	req.Header.Add("Accept", accept)
 	if userAgent := c.userAgent(); userAgent != "" {
 		req.Header.Add("User-Agent", userAgent)
 	}
@@ -1877,6 +1990,8 @@ func (c *client) readPaginatedResultsWithValues(path string, values url.Values,
 }
 
 func (c *client) readPaginatedResultsWithValuesWithContext(ctx context.Context, path string, values url.Values, accept, org string, newObj func() interface{}, accumulate func(interface{})) error {
+	ctx, cancel := c.withCallTimeout(ctx)
+	defer cancel()
 	pagedPath := path
 	if len(values) > 0 {
 		pagedPath += "?" + values.Encode()
@@ -4899,11 +5014,16 @@ func (c *client) UsesAppAuth() bool {
 //
 // See https://docs.github.com/en/free-pro-team@latest/rest/reference/apps#list-installations-for-the-authenticated-app
 func (c *client) ListAppInstallations() ([]AppInstallation, error) {
+	return c.ListAppInstallationsWithContext(context.Background())
+}
+
+func (c *client) ListAppInstallationsWithContext(ctx context.Context) ([]AppInstallation, error) {
 	durationLogger := c.log("AppInstallation")
 	defer durationLogger()
 
 	var ais []AppInstallation
-	if err := c.readPaginatedResults(
+	if err := c.readPaginatedResultsWithContext(
+		ctx,
 		"/app/installations",
 		acceptNone,
 		"",
@@ -5013,6 +5133,27 @@ func (c *client) GetAppWithContext(ctx context.Context) (*App, error) {
 	return &app, nil
 }
 
+// GetRateLimitWithContext gets the API budget of the credentials used for the
+// given org, which may be empty. The call does not count against the budget.
+//
+// See https://docs.github.com/en/rest/rate-limit#get-rate-limit-status-for-the-authenticated-user
+func (c *client) GetRateLimitWithContext(ctx context.Context, org string) (*RateLimits, error) {
+	durationLogger := c.log("GetRateLimit", org)
+	defer durationLogger()
+
+	var limits RateLimits
+	if _, err := c.requestWithContext(ctx, &request{
+		method:    http.MethodGet,
+		path:      "/rate_limit",
+		org:       org,
+		exitCodes: []int{200},
+	}, &limits); err != nil {
+		return nil, err
+	}
+
+	return &limits, nil
+}
+
 // GetDirectory uses GitHub repo contents API to retrieve the content of a directory with commit SHA.
 // If commit is empty, it will grab content from repo's default branch, usually master.
 //
@@ -19,7 +19,7 @@ package github
 import (
 	"bytes"
 	"context"
-	"crypto/rsa"
+	"crypto"
 	"crypto/tls"
 	"encoding/base64"
 	"encoding/json"
@@ -240,6 +240,112 @@ func TestRetryBase(t *testing.T) {
 	}
 }
 
+func TestBaseWeights(t *testing.T) {
+	var mainRequests, backupRequests int
+	main := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { mainRequests++ }))
+	defer main.Close()
+	backup := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { backupRequests++ }))
+	defer backup.Close()
+
+	testCases := []struct {
+		name        string
+		weights     []int
+		requests    int
+		expectMain  func(int) bool
+		description string
+	}{
+		{
+			name:        "no weights",
+			requests:    10,
+			expectMain:  func(n int) bool { return n == 10 },
+			description: "all requests",
+		},
+		{
+			name:        "zero weight is never picked first",
+			weights:     []int{0, 1},
+			requests:    10,
+			expectMain:  func(n int) bool { return n == 0 },
+			description: "no requests",
+		},
+		{
+			name:        "requests are distributed by weight",
+			weights:     []int{80, 20},
+			requests:    1000,
+			expectMain:  func(n int) bool { return n > 700 && n < 900 },
+			description: "about 800 requests",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mainRequests, backupRequests = 0, 0
+			c := getClient(main.URL)
+			c.bases = []string{main.URL, backup.URL}
+			c.baseWeights = tc.weights
+			for i := 0; i < tc.requests; i++ {
+				resp, err := c.requestRetry(http.MethodGet, "/", "", "", nil)
+				if err != nil {
+					t.Fatalf("Error from request: %v", err)
+				}
+				resp.Body.Close()
+			}
+			if !tc.expectMain(mainRequests) {
+				t.Errorf("Expected %s to go to the main base, got %d of %d", tc.description, mainRequests, tc.requests)
+			}
+			if mainRequests+backupRequests != tc.requests {
+				t.Errorf("Expected %d requests, got %d", tc.requests, mainRequests+backupRequests)
+			}
+		})
+	}
+}
+
+func TestCallTimeout(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(5 * time.Second):
+			}
+		}
+	}))
+	defer ts.Close()
+
+	testCases := []struct {
+		name        string
+		path        string
+		callTimeout time.Duration
+		expectErr   bool
+	}{
+		{
+			name:        "fast call",
+			path:        "/fast",
+			callTimeout: time.Second,
+		},
+		{
+			name:        "slow call times out",
+			path:        "/slow",
+			callTimeout: 10 * time.Millisecond,
+			expectErr:   true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := getClient(ts.URL)
+			c.callTimeout = tc.callTimeout
+			start := time.Now()
+			_, err := c.request(&request{method: http.MethodGet, path: tc.path, exitCodes: []int{200}}, nil)
+			if tc.expectErr != (err != nil) {
+				t.Fatalf("Expected error: %t, got %v", tc.expectErr, err)
+			}
+			if tc.expectErr && !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("Expected the call to exceed its deadline, got %v", err)
+			}
+			if elapsed := time.Since(start); elapsed > 2*time.Second {
+				t.Errorf("Expected the call timeout to be enforced, took %s", elapsed)
+			}
+		})
+	}
+}
+
 func TestIsMember(t *testing.T) {
 	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet {
@@ -260,6 +366,27 @@ func TestIsMember(t *testing.T) {
 	}
 }
 
+func TestGetRateLimitWithContext(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("Bad method: %s", r.Method)
+		}
+		if r.URL.Path != "/rate_limit" {
+			t.Errorf("Bad request path: %s", r.URL.Path)
+		}
+		fmt.Fprint(w, `{"resources": {"core": {"limit": 5000, "remaining": 4999, "used": 1, "reset": 1691591363}}}`)
+	}))
+	defer ts.Close()
+	c := getClient(ts.URL)
+	limits, err := c.GetRateLimitWithContext(context.Background(), "")
+	if err != nil {
+		t.Fatalf("Didn't expect error: %v", err)
+	}
+	if core := limits.Resources["core"]; core.Limit != 5000 || core.Remaining != 4999 {
+		t.Errorf("Unexpected core rate limit: %+v", core)
+	}
+}
+
 func TestCreateComment(t *testing.T) {
 	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
@@ -1077,6 +1204,50 @@ func TestIsNotFound_nested(t *testing.T) {
 
 }
 
+func TestIsRetryable(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name        string
+		err         error
+		expectMatch bool
+	}{
+		{
+			name:        "too many requests",
+			err:         requestError{StatusCode: http.StatusTooManyRequests},
+			expectMatch: true,
+		},
+		{
+			name:        "nested server error",
+			err:         fmt.Errorf("wrapping: %w", requestError{StatusCode: http.StatusBadGateway}),
+			expectMatch: true,
+		},
+		{
+			name:        "not found",
+			err:         requestError{StatusCode: http.StatusNotFound},
+			expectMatch: false,
+		},
+		{
+			name:        "no request error",
+			err:         errors.New("connection refused"),
+			expectMatch: false,
+		},
+		{
+			name:        "no error",
+			expectMatch: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			if result := IsRetryable(tc.err); result != tc.expectMatch {
+				t.Errorf("expected match: %t, got match: %t", tc.expectMatch, result)
+			}
+		})
+	}
+}
+
 func TestAssignIssue(t *testing.T) {
 	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
@@ -2895,6 +3066,93 @@ func TestAuthHeaderGetsSet(t *testing.T) {
 	}
 }
 
+func TestAcceptHeader(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name           string
+		acceptHeader   string
+		accept         string
+		expectedAccept string
+	}{
+		{
+			name:           "default",
+			expectedAccept: DefaultAcceptHeader,
+		},
+		{
+			name:           "configured accept header",
+			acceptHeader:   "application/vnd.github+json",
+			expectedAccept: "application/vnd.github+json",
+		},
+		{
+			name:           "accept header of the request wins",
+			acceptHeader:   "application/vnd.github+json",
+			accept:         "application/vnd.github.merge-info-preview+json",
+			expectedAccept: "application/vnd.github.merge-info-preview+json",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			fake := &fakeHttpClient{}
+			c := &client{delegate: &delegate{client: fake, acceptHeader: tc.acceptHeader, getToken: func() []byte { return nil }}, logger: logrus.NewEntry(logrus.New())}
+			if _, err := c.doRequest(context.Background(), http.MethodGet, "/hello", tc.accept, "", nil); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := fake.received[0].Header.Get("Accept"); got != tc.expectedAccept {
+				t.Errorf("expected Accept header %q, got %q", tc.expectedAccept, got)
+			}
+		})
+	}
+}
+
+func TestUserAgent(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name              string
+		userAgent         string
+		identifier        string
+		expectedUserAgent string
+	}{
+		{
+			name:              "default",
+			expectedUserAgent: version.UserAgent(),
+		},
+		{
+			name:              "default with identifier",
+			identifier:        "plugin",
+			expectedUserAgent: version.UserAgentWithIdentifier("plugin"),
+		},
+		{
+			name:              "configured user agent",
+			userAgent:         "my-bot/1.0",
+			expectedUserAgent: "my-bot/1.0",
+		},
+		{
+			name:              "configured user agent with identifier",
+			userAgent:         "my-bot/1.0",
+			identifier:        "plugin",
+			expectedUserAgent: "my-bot/1.0 (plugin)",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			fake := &fakeHttpClient{}
+			c := &client{identifier: tc.identifier, delegate: &delegate{client: fake, baseUserAgent: tc.userAgent, getToken: func() []byte { return nil }}, logger: logrus.NewEntry(logrus.New())}
+			if _, err := c.doRequest(context.Background(), http.MethodGet, "/hello", "", "", nil); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := fake.received[0].Header.Get("User-Agent"); got != tc.expectedUserAgent {
+				t.Errorf("expected User-Agent header %q, got %q", tc.expectedUserAgent, got)
+			}
+		})
+	}
+}
+
 func TestListTeamRepos(t *testing.T) {
 	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet {
@@ -3054,7 +3312,7 @@ func (rt testRoundTripper) RoundTrip(r *http.Request) (*http.Response, error) {
 // their arguments and calls them with an empty argument, then verifies via a RoundTripper that
 // all requests made had an org header set.
 func TestAllMethodsThatDoRequestSetOrgHeader(t *testing.T) {
-	_, _, ghClient, err := NewAppsAuthClientWithFields(logrus.Fields{}, func(_ []byte) []byte { return nil }, "some-app-id", func() *rsa.PrivateKey { return nil }, "", "https://api.github.com")
+	_, _, ghClient, err := NewAppsAuthClientWithFields(logrus.Fields{}, func(_ []byte) []byte { return nil }, "some-app-id", func() crypto.Signer { return nil }, "", "https://api.github.com")
 	if err != nil {
 		t.Fatalf("failed to construct github client: %v", err)
 	}
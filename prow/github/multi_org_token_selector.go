@@ -0,0 +1,60 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package github
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// orgPath matches the org in the path of REST API requests that target an
+// org or one of its repos, also behind a path prefix like /api/v3.
+var orgPath = regexp.MustCompile(`/(?:repos|orgs)/([^/]+)`)
+
+// MultiOrgTokenSelector is an http.RoundTripper that authenticates each
+// request with the token of the org it targets. The org is taken from the
+// request context if the client knows it, else from the request URL. Requests
+// for orgs without a token are passed on unchanged.
+type MultiOrgTokenSelector struct {
+	// Tokens maps lower case org names to a generator of their token.
+	Tokens map[string]func() []byte
+	// Upstream is the RoundTripper the requests are passed to. Defaults to
+	// http.DefaultTransport.
+	Upstream http.RoundTripper
+}
+
+func (s *MultiOrgTokenSelector) RoundTrip(r *http.Request) (*http.Response, error) {
+	upstream := s.Upstream
+	if upstream == nil {
+		upstream = http.DefaultTransport
+	}
+	org := extractOrgFromContext(r.Context())
+	if org == "" {
+		if match := orgPath.FindStringSubmatch(r.URL.Path); match != nil {
+			org = match[1]
+		}
+	}
+	getToken, found := s.Tokens[strings.ToLower(org)]
+	if !found {
+		return upstream.RoundTrip(r)
+	}
+	// RoundTrippers must not modify the request they were given.
+	r = r.Clone(r.Context())
+	r.Header.Set("Authorization", "Bearer "+string(getToken()))
+	return upstream.RoundTrip(r)
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package github
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestMultiOrgTokenSelector(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name         string
+		url          string
+		org          string
+		expectedAuth string
+	}{
+		{
+			name:         "org from repo path",
+			url:          "https://api.github.com/repos/kubernetes/test-infra/pulls",
+			expectedAuth: "Bearer k8s-token",
+		},
+		{
+			name:         "org from org path behind a prefix",
+			url:          "https://github.example.com/api/v3/orgs/Kubernetes-Sigs/members",
+			expectedAuth: "Bearer sigs-token",
+		},
+		{
+			name:         "org from context takes precedence",
+			url:          "https://api.github.com/repos/kubernetes/test-infra",
+			org:          "kubernetes-sigs",
+			expectedAuth: "Bearer sigs-token",
+		},
+		{
+			name:         "org without token is left alone",
+			url:          "https://api.github.com/repos/other/repo",
+			expectedAuth: "Bearer default-token",
+		},
+		{
+			name:         "request without org is left alone",
+			url:          "https://api.github.com/user",
+			expectedAuth: "Bearer default-token",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			upstream := &fakeRoundTripper{}
+			selector := &MultiOrgTokenSelector{
+				Tokens: map[string]func() []byte{
+					"kubernetes":      func() []byte { return []byte("k8s-token") },
+					"kubernetes-sigs": func() []byte { return []byte("sigs-token") },
+				},
+				Upstream: upstream,
+			}
+			ctx := context.Background()
+			if tc.org != "" {
+				ctx = context.WithValue(ctx, githubOrgHeaderKey, tc.org)
+			}
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, tc.url, nil)
+			if err != nil {
+				t.Fatalf("failed to create request: %v", err)
+			}
+			req.Header.Set("Authorization", "Bearer default-token")
+
+			if _, err := selector.RoundTrip(req); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := upstream.requests[0].Header.Get("Authorization"); got != tc.expectedAuth {
+				t.Errorf("expected authorization %q, got %q", tc.expectedAuth, got)
+			}
+			if got := req.Header.Get("Authorization"); got != "Bearer default-token" {
+				t.Errorf("original request was modified, authorization is %q", got)
+			}
+		})
+	}
+}
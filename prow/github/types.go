@@ -51,6 +51,10 @@ const (
 
 	// DefaultGraphQLEndpoint is the default GitHub GraphQL API endpoint.
 	DefaultGraphQLEndpoint = "https://api.github.com/graphql"
+
+	// DefaultAcceptHeader is the Accept header of REST API requests that do
+	// not need a specific media type.
+	DefaultAcceptHeader = "application/vnd.github.v3+json"
 )
 
 var (
@@ -1486,6 +1490,22 @@ type App struct {
 	Events      []string                 `json:"events,omitempty"`
 }
 
+// RateLimit is the API budget of a single GitHub API resource.
+//
+// See https://docs.github.com/en/rest/rate-limit
+type RateLimit struct {
+	Limit     int   `json:"limit"`
+	Remaining int   `json:"remaining"`
+	Used      int   `json:"used"`
+	Reset     int64 `json:"reset"`
+}
+
+// RateLimits is the API budget of the authenticated user, by API resource
+// like "core" or "graphql".
+type RateLimits struct {
+	Resources map[string]RateLimit `json:"resources"`
+}
+
 type InstallationPermissions struct {
 	Administration              string `json:"administration,omitempty"`
 	Blocking                    string `json:"blocking,omitempty"`